@@ -25,9 +25,23 @@ export interface ExifInfo {
   iso?: number
   aperture?: number
   shutterSpeed?: string
+  exposureTime?: number
   focalLength?: number
   gps?: GPSInfo
   takenAt?: string
+  utcOffset?: number
+  orientation?: number
+}
+
+// 由 GPS 离线解析的拍摄地点（中文名，*En 为英文名）；city 为空表示只定位到省/国家
+export interface Place {
+  countryCode: string
+  country: string
+  countryEn: string
+  province?: string
+  provinceEn?: string
+  city?: string
+  cityEn?: string
 }
 
 export interface Tag {
@@ -36,6 +50,15 @@ export interface Tag {
   score?: number
 }
 
+export interface Rendition {
+  path: string
+  width: number
+  height: number
+  size: number
+}
+
+export type ColorLabel = 'red' | 'yellow' | 'green' | 'blue' | 'purple'
+
 export interface Photo {
   id: string
   userId: string
@@ -45,10 +68,86 @@ export interface Photo {
   path: string
   thumbPath: string
   hash: string
+  phash?: string
   size: number
   mimeType: string
   exif?: ExifInfo
+  place?: Place
   tags?: Tag[]
+  renditions?: Record<string, Rendition>
+  rating: number
+  favorite: boolean
+  colorLabel?: ColorLabel
+  takenAt: string
+  deletedAt?: string
+  createdAt: string
+  updatedAt: string
+  // 关键词检索（q）时返回
+  score?: number
+  highlights?: SearchHighlights
+  // 附近检索（/photos/geo/near）时返回，单位米
+  distance?: number
+}
+
+// 匹配部分以 <em></em> 标出，其余内容已做 HTML 转义
+export interface SearchHighlights {
+  title?: string
+  description?: string
+  tags?: string[]
+}
+
+export interface SmartQuery {
+  q?: string
+  tags?: string[]
+  startDate?: string
+  endDate?: string
+  dateField?: 'uploaded' | 'taken'
+  make?: string
+  model?: string
+  hasGps?: boolean
+  minRating?: number
+  favorite?: boolean
+  colorLabel?: ColorLabel | 'none'
+}
+
+export type AlbumRole = 'viewer' | 'contributor' | 'editor' | 'owner'
+
+export interface AlbumMember {
+  userId: string
+  username: string
+  role: Exclude<AlbumRole, 'owner'>
+  addedAt: string
+}
+
+export interface Album {
+  id: string
+  userId: string
+  type: 'manual' | 'smart'
+  title: string
+  description: string
+  photoIds: string[]
+  coverPhotoId?: string
+  query?: SmartQuery
+  members: AlbumMember[]
+  role: AlbumRole
+  photoCount: number
+  cover?: Photo
+  createdAt: string
+  updatedAt: string
+}
+
+export interface Share {
+  id: string
+  userId: string
+  token: string
+  photoId?: string
+  albumId?: string
+  allowDownload: boolean
+  expiresAt?: string
+  views: number
+  lastViewedAt?: string
+  hasPassword: boolean
+  expired: boolean
   createdAt: string
   updatedAt: string
 }
@@ -70,21 +169,86 @@ export interface PhotoListParams {
   limit?: number
   q?: string
   tag?: string
+  tags?: string
+  tagMode?: 'all' | 'any'
+  excludeTags?: string
+  tagSource?: 'USER' | 'AI'
+  minTagScore?: number
   startDate?: string
   endDate?: string
+  dateField?: 'uploaded' | 'taken'
+  make?: string
+  model?: string
+  lens?: string
+  hasGps?: boolean
+  minIso?: number
+  maxIso?: number
+  minAperture?: number
+  maxAperture?: number
+  minFocalLength?: number
+  maxFocalLength?: number
+  minShutter?: string
+  maxShutter?: string
+  minRating?: number
+  favorite?: boolean
+  colorLabel?: ColorLabel | 'none'
+  sort?: PhotoSort
+  order?: 'asc' | 'desc'
+  cursor?: string
 }
 
+export type PhotoSort = 'createdAt' | 'takenAt' | 'size' | 'title' | 'rating' | 'relevance'
+
 export interface PhotoListResponse {
   data: Photo[]
   meta: {
     total: number
     page: number
     limit: number
+    nextCursor?: string
   }
 }
 
+export interface FacetCount {
+  value: string
+  count: number
+}
+
+export interface PhotoFacets {
+  total: number
+  tags: Record<string, FacetCount[]>
+  cameras: { make: string; model: string; count: number }[]
+  lenses: FacetCount[]
+  years: { year: number; count: number }[]
+  months: { year: number; month: number; count: number }[]
+}
+
+export interface GeoBounds {
+  west: number
+  south: number
+  east: number
+  north: number
+}
+
+export interface GeoCluster {
+  lat: number
+  lng: number
+  count: number
+  bounds: GeoBounds
+  photoId: string
+  photo?: Photo
+}
+
+export interface GeoClusterResponse {
+  data: GeoCluster[]
+  zoom: number
+}
+
 export interface UpdatePhotoRequest {
   title?: string
   description?: string
   tags?: Tag[]
+  rating?: number
+  favorite?: boolean
+  colorLabel?: ColorLabel | ''
 }
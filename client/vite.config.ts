@@ -21,7 +21,7 @@ export default defineConfig({
         target: 'http://localhost:8080',
         changeOrigin: true,
       },
-      '/uploads': {
+      '/media': {
         target: 'http://localhost:8080',
         changeOrigin: true,
       },
@@ -14,6 +14,8 @@ import (
 	"photoms/internal/models"
 	"photoms/internal/repository"
 	"photoms/pkg/config"
+	"photoms/pkg/textsearch"
+	"photoms/pkg/utils"
 
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -73,6 +75,8 @@ type toolContent struct {
 type mcpServer struct {
 	photoRepo       *repository.PhotoRepository
 	baseURL         string
+	mediaKey        string
+	mediaTTL        time.Duration
 	defaultUserID   *primitive.ObjectID
 	protocolVersion string
 }
@@ -108,6 +112,8 @@ func main() {
 	srv := &mcpServer{
 		photoRepo:     photoRepo,
 		baseURL:       baseURL,
+		mediaKey:      cfg.MediaSigningKey,
+		mediaTTL:      time.Duration(cfg.MediaURLTTLMinutes) * time.Minute,
 		defaultUserID: defaultUserID,
 	}
 
@@ -265,18 +271,44 @@ func (s *mcpServer) tools() []toolDefinition {
 	return []toolDefinition{
 		{
 			Name:        "search_photos",
-			Description: "在 PhotoMS 图片库中按关键词/标签/日期范围检索图片，返回匹配的图片列表（含可访问的原图/缩略图 URL）。",
+			Description: "在 PhotoMS 图片库中按关键词/标签/日期范围/星级/收藏/颜色标签检索图片，返回匹配的图片列表（含可访问的原图/缩略图 URL）。",
 			InputSchema: map[string]any{
 				"type": "object",
 				"properties": map[string]any{
 					"query": map[string]any{
 						"type":        "string",
-						"description": "关键词或自然语言检索（匹配标题/描述/标签）。",
+						"description": "关键词检索（匹配标题/描述/标签，支持中文；需包含全部关键词），默认按相关度排序并返回高亮片段。也支持检索语句，如 tag:狗 camera:\"Sony\" iso:>1600 taken:2024-05..2024-08 -tag:截图 has:gps；字段：tag、camera、make、model、lens、iso、aperture、focal、shutter、taken、uploaded、has:gps，前缀 - 表示排除。",
 					},
 					"tag": map[string]any{
 						"type":        "string",
 						"description": "单个标签过滤（精确匹配，忽略大小写）。",
 					},
+					"tags": map[string]any{
+						"type":        "array",
+						"items":       map[string]any{"type": "string"},
+						"maxItems":    repository.MaxFilterTags,
+						"description": "多个标签过滤（精确匹配，忽略大小写），匹配方式见 tagMode。",
+					},
+					"tagMode": map[string]any{
+						"type":        "string",
+						"enum":        []string{"all", "any"},
+						"description": "tags 的匹配方式：all（默认，全部包含）或 any（包含任一）。",
+					},
+					"excludeTags": map[string]any{
+						"type":        "array",
+						"items":       map[string]any{"type": "string"},
+						"maxItems":    repository.MaxFilterTags,
+						"description": "排除包含这些标签的图片。",
+					},
+					"tagSource": map[string]any{
+						"type":        "string",
+						"enum":        []string{"USER", "AI"},
+						"description": "只按该来源的标签匹配 tag/tags（用户标签或 AI 标签）；未指定标签时要求图片有该来源的标签。",
+					},
+					"minTagScore": map[string]any{
+						"type":        "number",
+						"description": "AI 标签的最低置信度（0~1），置信度更低的 AI 标签不参与匹配。",
+					},
 					"startDate": map[string]any{
 						"type":        "string",
 						"description": "起始日期（YYYY-MM-DD 或 RFC3339）。",
@@ -285,6 +317,33 @@ func (s *mcpServer) tools() []toolDefinition {
 						"type":        "string",
 						"description": "结束日期（YYYY-MM-DD 或 RFC3339）。",
 					},
+					"dateField": map[string]any{
+						"type":        "string",
+						"enum":        []string{"uploaded", "taken"},
+						"description": "startDate/endDate 作用的时间：uploaded（默认，上传时间）或 taken（拍摄时间，按拍摄地当地日期；无 EXIF 时使用上传时间）。",
+					},
+					"minRating": map[string]any{
+						"type":        "integer",
+						"description": "最低星级（0~5）。",
+					},
+					"favorite": map[string]any{
+						"type":        "boolean",
+						"description": "true 只返回收藏的图片，false 只返回未收藏的图片。",
+					},
+					"colorLabel": map[string]any{
+						"type":        "string",
+						"description": "颜色标签：red/yellow/green/blue/purple，none 表示没有颜色标签。",
+					},
+					"sort": map[string]any{
+						"type":        "string",
+						"enum":        []string{"createdAt", "takenAt", "size", "title", "rating", "relevance"},
+						"description": "排序字段：createdAt（无 query 时默认，上传时间）、takenAt（拍摄时间）、size、title、rating、relevance（有 query 时默认，相关度）。",
+					},
+					"order": map[string]any{
+						"type":        "string",
+						"enum":        []string{"asc", "desc"},
+						"description": "排序方向，默认 desc（按 title 排序时默认 asc）。",
+					},
 					"page": map[string]any{
 						"type":        "integer",
 						"description": "分页页码（从 1 开始）。",
@@ -371,6 +430,65 @@ func (s *mcpServer) toolSearchPhotos(id json.RawMessage, args map[string]any) rp
 		return toolErrorResponse(id, fmt.Sprintf("invalid endDate: %v", err))
 	}
 
+	filter := repository.PhotoFilter{
+		Q:         query,
+		Tag:       tag,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Favorite:  getBoolArg(args, "favorite"),
+	}
+	filter.Tags = getStringListArg(args, "tags")
+	filter.ExcludeTags = getStringListArg(args, "excludeTags")
+	if len(filter.Tags) > repository.MaxFilterTags || len(filter.ExcludeTags) > repository.MaxFilterTags {
+		return toolErrorResponse(id, fmt.Sprintf("invalid tags (at most %d tags)", repository.MaxFilterTags))
+	}
+	switch mode := strings.ToLower(strings.TrimSpace(getStringArg(args, "tagMode"))); mode {
+	case "", repository.TagModeAll, repository.TagModeAny:
+		filter.TagMode = mode
+	default:
+		return toolErrorResponse(id, "invalid tagMode (must be all or any)")
+	}
+	switch source := strings.ToUpper(strings.TrimSpace(getStringArg(args, "tagSource"))); source {
+	case "", models.TagSourceUser, models.TagSourceAI:
+		filter.TagSource = source
+	default:
+		return toolErrorResponse(id, "invalid tagSource (must be USER or AI)")
+	}
+	if score, ok := getFloatArg(args, "minTagScore"); ok {
+		if score < 0 || score > 1 {
+			return toolErrorResponse(id, "invalid minTagScore (must be 0~1)")
+		}
+		filter.MinTagScore = &score
+	}
+	switch dateField := strings.TrimSpace(getStringArg(args, "dateField")); dateField {
+	case "", repository.DateFieldUploaded, repository.DateFieldTaken:
+		filter.DateField = dateField
+	default:
+		return toolErrorResponse(id, "invalid dateField (must be uploaded or taken)")
+	}
+	if _, ok := args["minRating"]; ok {
+		minRating := getIntArg(args, "minRating", 0)
+		if minRating < 0 || minRating > 5 {
+			return toolErrorResponse(id, "invalid minRating (must be 0~5)")
+		}
+		filter.MinRating = &minRating
+	}
+	if label := strings.TrimSpace(getStringArg(args, "colorLabel")); strings.EqualFold(label, repository.ColorLabelNone) {
+		filter.ColorLabel = repository.ColorLabelNone
+	} else if label, ok := models.NormalizeColorLabel(label); ok {
+		filter.ColorLabel = label
+	} else {
+		return toolErrorResponse(id, "invalid colorLabel (must be red/yellow/green/blue/purple or none)")
+	}
+	if err := repository.ParsePhotoQuery(query, &filter); err != nil {
+		return toolErrorResponse(id, err.Error())
+	}
+	filter.Sort = strings.TrimSpace(getStringArg(args, "sort"))
+	filter.Order = strings.ToLower(strings.TrimSpace(getStringArg(args, "order")))
+	if !repository.IsValidPhotoSort(filter.Sort, filter.Order) {
+		return toolErrorResponse(id, "invalid sort (must be createdAt/takenAt/size/title/rating/relevance, order asc/desc)")
+	}
+
 	var userID *primitive.ObjectID
 	if userIDStr != "" {
 		oid, err := primitive.ObjectIDFromHex(userIDStr)
@@ -385,19 +503,28 @@ func (s *mcpServer) toolSearchPhotos(id json.RawMessage, args map[string]any) rp
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	photos, total, err := s.photoRepo.Find(ctx, userID, int64(page), int64(limit), query, tag, startDate, endDate)
+	filter.UserID = userID
+	photos, total, err := s.photoRepo.Find(ctx, filter, int64(page), int64(limit))
 	if err != nil {
 		return toolErrorResponse(id, fmt.Sprintf("search failed: %v", err))
 	}
 
 	type item struct {
-		ID          string   `json:"id"`
-		Title       string   `json:"title"`
-		Description string   `json:"description,omitempty"`
-		Tags        []string `json:"tags,omitempty"`
-		CreatedAt   string   `json:"createdAt"`
-		URL         string   `json:"url"`
-		ThumbURL    string   `json:"thumbUrl"`
+		ID          string        `json:"id"`
+		Title       string        `json:"title"`
+		Description string        `json:"description,omitempty"`
+		Tags        []string      `json:"tags,omitempty"`
+		Rating      int           `json:"rating,omitempty"`
+		Favorite    bool          `json:"favorite,omitempty"`
+		ColorLabel  string        `json:"colorLabel,omitempty"`
+		Place       *models.Place `json:"place,omitempty"`
+		TakenAt     string        `json:"takenAt"`
+		CreatedAt   string        `json:"createdAt"`
+		URL         string        `json:"url"`
+		ThumbURL    string        `json:"thumbUrl"`
+		Score       float64       `json:"score,omitempty"`
+		// Highlights 匹配部分以 <em></em> 标出
+		Highlights *models.SearchHighlights `json:"highlights,omitempty"`
 	}
 
 	items := make([]item, 0, len(photos))
@@ -416,9 +543,16 @@ func (s *mcpServer) toolSearchPhotos(id json.RawMessage, args map[string]any) rp
 			Title:       p.Title,
 			Description: p.Description,
 			Tags:        tags,
+			Rating:      p.Rating,
+			Favorite:    p.Favorite,
+			ColorLabel:  p.ColorLabel,
+			Place:       p.Place,
+			TakenAt:     p.TakenAt.Time().UTC().Format("2006-01-02T15:04:05"), // 拍摄地当地时间，不带时区
 			CreatedAt:   p.CreatedAt.Time().Format(time.RFC3339),
-			URL:         resolveURL(s.baseURL, p.Path),
-			ThumbURL:    resolveURL(s.baseURL, p.ThumbPath),
+			URL:         s.mediaURL(p.Path),
+			ThumbURL:    s.mediaURL(p.ThumbPath),
+			Score:       p.Score,
+			Highlights:  textsearch.Highlight(p, filter.Q),
 		})
 	}
 
@@ -457,17 +591,29 @@ func (s *mcpServer) toolGetPhoto(id json.RawMessage, args map[string]any) rpcRes
 	if err != nil {
 		return toolErrorResponse(id, fmt.Sprintf("photo not found: %v", err))
 	}
+	if photo.DeletedAt != nil {
+		return toolErrorResponse(id, "photo not found: photo is in trash")
+	}
 
 	type out struct {
-		ID          string       `json:"id"`
-		UserID      string       `json:"userId"`
-		Title       string       `json:"title"`
-		Description string       `json:"description"`
-		Tags        []models.Tag `json:"tags"`
-		CreatedAt   string       `json:"createdAt"`
-		UpdatedAt   string       `json:"updatedAt"`
-		URL         string       `json:"url"`
-		ThumbURL    string       `json:"thumbUrl"`
+		ID          string            `json:"id"`
+		UserID      string            `json:"userId"`
+		Title       string            `json:"title"`
+		Description string            `json:"description"`
+		Tags        []models.Tag      `json:"tags"`
+		CreatedAt   string            `json:"createdAt"`
+		UpdatedAt   string            `json:"updatedAt"`
+		URL         string            `json:"url"`
+		ThumbURL    string            `json:"thumbUrl"`
+		Renditions  map[string]string `json:"renditions,omitempty"`
+	}
+
+	var renditions map[string]string
+	if len(photo.Renditions) > 0 {
+		renditions = make(map[string]string, len(photo.Renditions))
+		for name, r := range photo.Renditions {
+			renditions[name] = s.mediaURL(r.Path)
+		}
 	}
 
 	payload, _ := json.MarshalIndent(out{
@@ -478,8 +624,9 @@ func (s *mcpServer) toolGetPhoto(id json.RawMessage, args map[string]any) rpcRes
 		Tags:        photo.Tags,
 		CreatedAt:   photo.CreatedAt.Time().Format(time.RFC3339),
 		UpdatedAt:   photo.UpdatedAt.Time().Format(time.RFC3339),
-		URL:         resolveURL(s.baseURL, photo.Path),
-		ThumbURL:    resolveURL(s.baseURL, photo.ThumbPath),
+		URL:         s.mediaURL(photo.Path),
+		ThumbURL:    s.mediaURL(photo.ThumbPath),
+		Renditions:  renditions,
 	}, "", "  ")
 
 	return rpcResponse{
@@ -531,6 +678,62 @@ func getStringArg(args map[string]any, key string) string {
 	}
 }
 
+// getStringListArg 读取字符串数组参数（也接受逗号分隔的字符串），忽略空项
+func getStringListArg(args map[string]any, key string) []string {
+	var items []string
+	switch t := args[key].(type) {
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				items = append(items, s)
+			}
+		}
+	case string:
+		items = strings.Split(t, ",")
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// getFloatArg 读取可选的数值参数，未提供或无法解析时 ok 为 false
+func getFloatArg(args map[string]any, key string) (float64, bool) {
+	switch t := args[key].(type) {
+	case float64:
+		return t, true
+	case string:
+		return utils.ParseFinite(strings.TrimSpace(t))
+	default:
+		return 0, false
+	}
+}
+
+// getBoolArg 读取可选的布尔参数，未提供或无法解析时返回 nil
+func getBoolArg(args map[string]any, key string) *bool {
+	if args == nil {
+		return nil
+	}
+	switch t := args[key].(type) {
+	case bool:
+		return &t
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(t))
+		if err != nil {
+			return nil
+		}
+		return &b
+	default:
+		return nil
+	}
+}
+
 func getIntArg(args map[string]any, key string, def int) int {
 	if args == nil {
 		return def
@@ -569,7 +772,7 @@ func parseDate(value string, endOfDay bool) (*time.Time, error) {
 
 	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
 		if endOfDay {
-			t = t.Add(24*time.Hour - time.Nanosecond)
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
 		}
 		return &t, nil
 	}
@@ -577,6 +780,15 @@ func parseDate(value string, endOfDay bool) (*time.Time, error) {
 	return nil, fmt.Errorf("invalid date format: %s", value)
 }
 
+// mediaURL 生成带签名、会过期的图片访问地址（媒体文件不再公开访问）
+func (s *mcpServer) mediaURL(path string) string {
+	key := utils.MediaKeyFromPath(path)
+	if key == "" {
+		return ""
+	}
+	return resolveURL(s.baseURL, utils.SignMediaPath(key, s.mediaKey, utils.MediaExpiry(time.Now(), s.mediaTTL)))
+}
+
 func resolveURL(baseURL, path string) string {
 	p := strings.TrimSpace(path)
 	if p == "" {
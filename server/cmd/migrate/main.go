@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"photoms/internal/models"
+	"photoms/internal/repository"
+	"photoms/internal/service"
+	"photoms/pkg/config"
+	"photoms/pkg/storage"
+	"photoms/pkg/utils"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 数据迁移/回填工具：go run ./cmd/migrate <task> [flags]
+// 每个任务都可重复执行（已处理的记录会被跳过）。
+
+type migrateEnv struct {
+	cfg          *config.Config
+	db           *mongo.Database
+	photoRepo    *repository.PhotoRepository
+	photoService *service.PhotoService
+}
+
+type task struct {
+	description string
+	run         func(ctx context.Context, env *migrateEnv, args []string) error
+}
+
+var tasks = map[string]task{
+	"regenerate-thumbnails": {
+		description: "按 EXIF 方向重新生成缩略图规格（默认只处理需要旋转或缺少规格的图片，-all 处理全部）",
+		run:         regenerateThumbnails,
+	},
+	"backfill-phash": {
+		description: "为历史图片计算感知哈希（近似重复检测）",
+		run:         backfillPHash,
+	},
+	"backfill-exposure-time": {
+		description: "根据已保存的快门速度补齐曝光时间（秒）与品牌/型号/镜头的小写匹配字段，并创建 EXIF 过滤所需的索引",
+		run:         backfillExposureTime,
+	},
+	"backfill-sort-fields": {
+		description: "为历史图片补齐排序字段（拍摄地时间 taken_at、星级 rating），游标分页与按拍摄时间排序/过滤依赖这些字段（-all 重新计算全部）",
+		run:         backfillSortFields,
+	},
+	"reindex-search": {
+		description: "为历史图片建立关键词检索词并创建检索索引（默认只处理没有检索词的图片，-all 重建全部，如调整了分词规则）",
+		run:         reindexSearch,
+	},
+	"backfill-location": {
+		description: "为有 GPS 信息的历史图片生成 GeoJSON 位置并创建地理索引（地图与范围检索依赖）",
+		run:         backfillLocation,
+	},
+	"backfill-places": {
+		description: "为有 GPS 信息的历史图片离线解析拍摄地点（国家/省/城市），并追加中英文地点标签以便关键词检索（默认只处理没有地点的图片，-all 重新解析全部）",
+		run:         backfillPlaces,
+	},
+}
+
+func main() {
+	log.SetFlags(log.LstdFlags)
+
+	if len(os.Args) < 2 || tasks[os.Args[1]].run == nil {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+	cfg := config.Load()
+
+	client, err := connectMongo(cfg.MongoURI)
+	if err != nil {
+		log.Fatalf("mongo connect failed: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	db := client.Database(cfg.DatabaseName)
+	backend, err := storage.NewBackend(cfg)
+	if err != nil {
+		log.Fatalf("failed to initialize storage backend: %v", err)
+	}
+	photoRepo := repository.NewPhotoRepository(db)
+
+	env := &migrateEnv{
+		cfg:          cfg,
+		db:           db,
+		photoRepo:    photoRepo,
+		photoService: service.NewPhotoService(photoRepo, repository.NewAlbumRepository(db), backend, cfg),
+	}
+
+	start := time.Now()
+	if err := tasks[name].run(context.Background(), env, os.Args[2:]); err != nil {
+		log.Fatalf("%s failed: %v", name, err)
+	}
+	log.Printf("%s finished in %s", name, time.Since(start).Round(time.Millisecond))
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: migrate <task> [flags]")
+	fmt.Fprintln(os.Stderr, "\nTasks:")
+	names := make([]string, 0, len(tasks))
+	for name := range tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-24s %s\n", name, tasks[name].description)
+	}
+}
+
+func regenerateThumbnails(ctx context.Context, env *migrateEnv, args []string) error {
+	fs := flag.NewFlagSet("regenerate-thumbnails", flag.ExitOnError)
+	all := fs.Bool("all", false, "regenerate every photo, not only rotated ones or ones without renditions")
+	_ = fs.Parse(args)
+
+	// 秒传副本共用文件，每个文件只处理一次
+	seen := make(map[string]struct{})
+	var scanned, regenerated, failed int
+
+	err := env.photoRepo.ForEach(ctx, bson.M{}, func(photo *models.Photo) error {
+		if _, ok := seen[photo.FileName]; ok || photo.FileName == "" {
+			return nil
+		}
+		seen[photo.FileName] = struct{}{}
+		scanned++
+
+		done, err := env.photoService.RegenerateRenditions(ctx, photo, *all)
+		if err != nil {
+			failed++
+			log.Printf("photo %s (%s): %v", photo.ID.Hex(), photo.FileName, err)
+			return nil
+		}
+		if done {
+			regenerated++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("scanned %d files, regenerated %d, failed %d", scanned, regenerated, failed)
+	return nil
+}
+
+func backfillPHash(ctx context.Context, env *migrateEnv, args []string) error {
+	fs := flag.NewFlagSet("backfill-phash", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	seen := make(map[string]struct{})
+	var updated, failed int
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"phash": bson.M{"$exists": false}},
+		bson.M{"phash": ""},
+	}}
+	err := env.photoRepo.ForEach(ctx, filter, func(photo *models.Photo) error {
+		if _, ok := seen[photo.FileName]; ok || photo.FileName == "" {
+			return nil
+		}
+		seen[photo.FileName] = struct{}{}
+
+		if err := env.photoService.BackfillPerceptualHash(ctx, photo); err != nil {
+			failed++
+			log.Printf("photo %s (%s): %v", photo.ID.Hex(), photo.FileName, err)
+			return nil
+		}
+		updated++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("updated %d files, failed %d", updated, failed)
+	return nil
+}
+
+func backfillSortFields(ctx context.Context, env *migrateEnv, args []string) error {
+	fs := flag.NewFlagSet("backfill-sort-fields", flag.ExitOnError)
+	all := fs.Bool("all", false, "recompute taken_at for every photo, not only ones without it")
+	_ = fs.Parse(args)
+
+	if err := env.photoRepo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	rated, err := env.photoRepo.BackfillRating(ctx)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"taken_at": bson.M{"$exists": false}}
+	if *all {
+		filter = bson.M{}
+	}
+	var updated, failed int
+	err = env.photoRepo.ForEach(ctx, filter, func(photo *models.Photo) error {
+		takenAt := utils.CaptureTime(photo.Exif, photo.CreatedAt.Time())
+		if takenAt == photo.TakenAt {
+			return nil
+		}
+		if err := env.photoRepo.Update(ctx, photo.ID, bson.M{"taken_at": takenAt}); err != nil {
+			failed++
+			log.Printf("photo %s: %v", photo.ID.Hex(), err)
+			return nil
+		}
+		updated++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("set rating on %d photos, updated taken_at on %d, failed %d", rated, updated, failed)
+	return nil
+}
+
+func backfillExposureTime(ctx context.Context, env *migrateEnv, args []string) error {
+	fs := flag.NewFlagSet("backfill-exposure-time", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	if err := env.photoRepo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	filter := bson.M{
+		"exif.shutter_speed": bson.M{"$exists": true},
+		"exif.exposure_time": bson.M{"$exists": false},
+	}
+	var updated, skipped int
+	err := env.photoRepo.ForEach(ctx, filter, func(photo *models.Photo) error {
+		seconds, ok := utils.ParseShutterSpeed(photo.Exif.ShutterSpeed)
+		if !ok || seconds == 0 {
+			skipped++
+			return nil
+		}
+		if err := env.photoRepo.Update(ctx, photo.ID, bson.M{"exif.exposure_time": seconds}); err != nil {
+			return err
+		}
+		updated++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("updated %d photos, skipped %d unparsable shutter speeds", updated, skipped)
+
+	keyed := 0
+	filter = bson.M{"$or": bson.A{
+		bson.M{"exif.make": bson.M{"$exists": true}, "exif.make_key": bson.M{"$exists": false}},
+		bson.M{"exif.model": bson.M{"$exists": true}, "exif.model_key": bson.M{"$exists": false}},
+		bson.M{"exif.lens": bson.M{"$exists": true}, "exif.lens_key": bson.M{"$exists": false}},
+	}}
+	err = env.photoRepo.ForEach(ctx, filter, func(photo *models.Photo) error {
+		photo.Exif.SetMatchKeys()
+		if err := env.photoRepo.Update(ctx, photo.ID, bson.M{
+			"exif.make_key":  photo.Exif.MakeKey,
+			"exif.model_key": photo.Exif.ModelKey,
+			"exif.lens_key":  photo.Exif.LensKey,
+		}); err != nil {
+			return err
+		}
+		keyed++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("set make/model/lens match keys on %d photos", keyed)
+	return nil
+}
+
+func reindexSearch(ctx context.Context, env *migrateEnv, args []string) error {
+	fs := flag.NewFlagSet("reindex-search", flag.ExitOnError)
+	all := fs.Bool("all", false, "rebuild search terms for every photo, not only ones without them")
+	_ = fs.Parse(args)
+
+	if err := env.photoRepo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	filter := bson.M{"search": bson.M{"$exists": false}}
+	if *all {
+		filter = bson.M{}
+	}
+	var updated, failed int
+	err := env.photoRepo.ForEach(ctx, filter, func(photo *models.Photo) error {
+		if err := env.photoRepo.RefreshSearchIndex(ctx, photo.ID); err != nil {
+			failed++
+			log.Printf("photo %s: %v", photo.ID.Hex(), err)
+			return nil
+		}
+		updated++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("indexed %d photos, failed %d", updated, failed)
+	return nil
+}
+
+func backfillLocation(ctx context.Context, env *migrateEnv, args []string) error {
+	fs := flag.NewFlagSet("backfill-location", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	if err := env.photoRepo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	updated, err := env.photoRepo.BackfillLocation(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("set location on %d photos", updated)
+	return nil
+}
+
+func backfillPlaces(ctx context.Context, env *migrateEnv, args []string) error {
+	fs := flag.NewFlagSet("backfill-places", flag.ExitOnError)
+	all := fs.Bool("all", false, "resolve places for every photo with GPS, not only ones without a place")
+	_ = fs.Parse(args)
+
+	filter := bson.M{"exif.gps": bson.M{"$exists": true}}
+	if !*all {
+		filter["place"] = bson.M{"$exists": false}
+	}
+	var updated, skipped, failed int
+	err := env.photoRepo.ForEach(ctx, filter, func(photo *models.Photo) error {
+		done, err := env.photoService.BackfillPlace(ctx, photo)
+		if err != nil {
+			failed++
+			log.Printf("photo %s: %v", photo.ID.Hex(), err)
+			return nil
+		}
+		if done {
+			updated++
+		} else {
+			skipped++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("resolved %d photos, no nearby place %d, failed %d", updated, skipped, failed)
+	return nil
+}
+
+func connectMongo(uri string) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, err
+	}
+	return client, nil
+}
@@ -8,6 +8,7 @@ import (
 	"photoms/internal/repository"
 	"photoms/internal/service"
 	"photoms/pkg/config"
+	"photoms/pkg/storage"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -46,14 +47,44 @@ func main() {
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	photoRepo := repository.NewPhotoRepository(db)
+	uploadSessionRepo := repository.NewUploadSessionRepository(db)
+	albumRepo := repository.NewAlbumRepository(db)
+	shareRepo := repository.NewShareRepository(db)
+
+	if err := photoRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatal("Failed to create photo indexes:", err)
+	}
+	if err := shareRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatal("Failed to create share indexes:", err)
+	}
+
+	// Initialize storage backend
+	backend, err := storage.NewBackend(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize storage backend:", err)
+	}
 
 	// Initialize services
 	authService := service.NewAuthService(userRepo, cfg)
-	photoService := service.NewPhotoService(photoRepo, cfg) // 注入 photoRepo
+	photoService := service.NewPhotoService(photoRepo, albumRepo, backend, cfg) // 注入 photoRepo
+	uploadService := service.NewUploadService(uploadSessionRepo, photoService, cfg)
+	albumService := service.NewAlbumService(albumRepo, photoRepo, userRepo)
+	shareService := service.NewShareService(shareRepo, photoService, albumService)
+	bulkService := service.NewBulkService(photoRepo, photoService, albumService)
+
+	// 后台清理过期的断点续传会话
+	uploadService.StartCleanup(context.Background())
+	// 后台彻底删除超过保留期的回收站图片
+	photoService.StartTrashPurger(context.Background())
 
 	// Initialize controllers
 	authController := controller.NewAuthController(authService)
 	photoController := controller.NewPhotoController(photoService)
+	uploadController := controller.NewUploadController(uploadService, photoService)
+	mediaController := controller.NewMediaController(photoService)
+	albumController := controller.NewAlbumController(albumService, photoService)
+	shareController := controller.NewShareController(shareService)
+	bulkController := controller.NewBulkController(bulkService)
 
 	// Setup Gin router
 	router := gin.Default()
@@ -61,9 +92,9 @@ func main() {
 	// CORS middleware
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     cfg.AllowedOrigins,
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Upload-Offset", "Upload-Length", "X-Share-Password"},
+		ExposeHeaders:    []string{"Content-Length", "Location", "Upload-Offset", "Upload-Length", "Upload-Expires"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -77,22 +108,81 @@ func main() {
 			auth.POST("/login", authController.Login)
 		}
 
+		// 公开分享链接（无需登录，持有 Token 即可访问）
+		shared := api.Group("/s")
+		{
+			shared.GET("/:token", shareController.View)
+			shared.GET("/:token/photos", shareController.Photos)
+			shared.GET("/:token/file", shareController.File)
+		}
+
 		// Protected routes
 		photos := api.Group("/photos")
 		photos.Use(middleware.AuthMiddleware(cfg))
 		{
 			photos.POST("", photoController.Upload)
+			photos.POST("/batch", photoController.BatchUpload)
+			photos.POST("/bulk", bulkController.Apply)
 			photos.GET("", photoController.List)
+			photos.GET("/facets", photoController.Facets)
+			photos.GET("/geo/within", photoController.Within)
+			photos.GET("/geo/near", photoController.Near)
+			photos.GET("/geo/clusters", photoController.Clusters)
+			photos.GET("/duplicates", photoController.Duplicates)
+			photos.GET("/trash", photoController.Trash)
+			photos.DELETE("/trash", photoController.EmptyTrash)
+			photos.DELETE("/trash/:id", photoController.Purge)
 			photos.GET("/:id", photoController.GetByID)
+			photos.GET("/:id/file", photoController.File)
+			photos.GET("/:id/render", photoController.Render)
 			photos.PUT("/:id", photoController.Update)
 			photos.DELETE("/:id", photoController.Delete)
+			photos.POST("/:id/restore", photoController.Restore)
 			photos.POST("/:id/ai-tags", photoController.GenerateAITags)
 			photos.POST("/:id/edit", photoController.Edit)
 		}
+
+		albums := api.Group("/albums")
+		albums.Use(middleware.AuthMiddleware(cfg))
+		{
+			albums.POST("", albumController.Create)
+			albums.GET("", albumController.List)
+			albums.GET("/shared", albumController.Shared)
+			albums.GET("/:id", albumController.GetByID)
+			albums.PUT("/:id", albumController.Update)
+			albums.DELETE("/:id", albumController.Delete)
+			albums.GET("/:id/photos", albumController.ListPhotos)
+			albums.POST("/:id/photos", albumController.AddPhotos)
+			albums.DELETE("/:id/photos", albumController.RemovePhotos)
+			albums.PUT("/:id/photos/order", albumController.ReorderPhotos)
+			albums.PUT("/:id/cover", albumController.SetCover)
+			albums.POST("/:id/members", albumController.AddMember)
+			albums.PUT("/:id/members/:userId", albumController.UpdateMember)
+			albums.DELETE("/:id/members/:userId", albumController.RemoveMember)
+		}
+
+		shares := api.Group("/shares")
+		shares.Use(middleware.AuthMiddleware(cfg))
+		{
+			shares.POST("", shareController.Create)
+			shares.GET("", shareController.List)
+			shares.DELETE("/:id", shareController.Revoke)
+		}
+
+		// Resumable uploads (tus-style)
+		uploads := api.Group("/uploads")
+		uploads.Use(middleware.AuthMiddleware(cfg))
+		{
+			uploads.POST("", uploadController.Create)
+			uploads.HEAD("/:id", uploadController.Head)
+			uploads.PATCH("/:id", uploadController.Patch)
+			uploads.POST("/:id/complete", uploadController.Complete)
+			uploads.DELETE("/:id", uploadController.Delete)
+		}
 	}
 
-	// Serve uploaded files
-	router.Static("/uploads", cfg.UploadDir)
+	// Serve uploaded files: 不再公开静态目录，仅允许通过带签名、会过期的 URL 访问
+	router.GET("/media/*key", mediaController.Serve)
 
 	log.Printf("Server starting on port %s", cfg.Port)
 	if err := router.Run(":" + cfg.Port); err != nil {
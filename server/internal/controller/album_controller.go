@@ -0,0 +1,472 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"photoms/internal/models"
+	"photoms/internal/service"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AlbumController 相册接口：CRUD、添加/移除图片、手动排序、封面与相册内图片列表
+type AlbumController struct {
+	albumService *service.AlbumService
+	photoService *service.PhotoService
+}
+
+func NewAlbumController(albumService *service.AlbumService, photoService *service.PhotoService) *AlbumController {
+	return &AlbumController{albumService: albumService, photoService: photoService}
+}
+
+type CreateAlbumRequest struct {
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+	// Query 非空时创建智能相册
+	Query *SmartQueryRequest `json:"query"`
+}
+
+type UpdateAlbumRequest struct {
+	Title       *string            `json:"title"`
+	Description *string            `json:"description"`
+	Query       *SmartQueryRequest `json:"query"`
+}
+
+// SmartQueryRequest 智能相册查询条件，日期格式与图片列表的 startDate/endDate 相同
+type SmartQueryRequest struct {
+	Q          string   `json:"q"`
+	Tags       []string `json:"tags"`
+	StartDate  string   `json:"startDate"`
+	EndDate    string   `json:"endDate"`
+	DateField  string   `json:"dateField"`
+	Make       string   `json:"make"`
+	Model      string   `json:"model"`
+	HasGPS     *bool    `json:"hasGps"`
+	MinRating  *int     `json:"minRating"`
+	Favorite   *bool    `json:"favorite"`
+	ColorLabel string   `json:"colorLabel"`
+}
+
+func (r *SmartQueryRequest) toModel() (*models.SmartQuery, error) {
+	if r == nil {
+		return nil, nil
+	}
+	startDate, err := parseDateQuery(r.StartDate, false)
+	if err != nil {
+		return nil, err
+	}
+	endDate, err := parseDateQuery(r.EndDate, true)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &models.SmartQuery{
+		Q:          r.Q,
+		Tags:       r.Tags,
+		DateField:  r.DateField,
+		Make:       r.Make,
+		Model:      r.Model,
+		HasGPS:     r.HasGPS,
+		MinRating:  r.MinRating,
+		Favorite:   r.Favorite,
+		ColorLabel: r.ColorLabel,
+	}
+	if startDate != nil {
+		dt := primitive.NewDateTimeFromTime(*startDate)
+		q.StartDate = &dt
+	}
+	if endDate != nil {
+		dt := primitive.NewDateTimeFromTime(*endDate)
+		q.EndDate = &dt
+	}
+	return q, nil
+}
+
+type AlbumPhotosRequest struct {
+	PhotoIDs []string `json:"photoIds" binding:"required"`
+}
+
+type AlbumCoverRequest struct {
+	PhotoID *string `json:"photoId"`
+}
+
+type AddAlbumMemberRequest struct {
+	Email string `json:"email" binding:"required"`
+	Role  string `json:"role" binding:"required"`
+}
+
+type UpdateAlbumMemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func (ctrl *AlbumController) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req CreateAlbumRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err := req.Query.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	album, err := ctrl.albumService.CreateAlbum(c.Request.Context(), userID, req.Title, req.Description, query)
+	if err != nil {
+		c.JSON(albumErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, ctrl.present(album))
+}
+
+// List 分页列出相册（?type=manual|smart 过滤类型），智能相册返回实时的图片数量
+func (ctrl *AlbumController) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	page, limit := pageQuery(c)
+
+	albumType := strings.TrimSpace(c.Query("type"))
+	if albumType != "" && albumType != models.AlbumTypeManual && albumType != models.AlbumTypeSmart {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be manual or smart"})
+		return
+	}
+
+	albums, total, err := ctrl.albumService.ListAlbums(c.Request.Context(), userID, albumType, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, album := range albums {
+		ctrl.present(album)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": albums,
+		"meta": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+// Shared 分页列出他人共享给我的相册
+func (ctrl *AlbumController) Shared(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	page, limit := pageQuery(c)
+
+	albums, total, err := ctrl.albumService.ListSharedAlbums(c.Request.Context(), userID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, album := range albums {
+		ctrl.present(album)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": albums,
+		"meta": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+func (ctrl *AlbumController) GetByID(c *gin.Context) {
+	userID, albumID, ok := albumRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	album, err := ctrl.albumService.GetAlbum(c.Request.Context(), albumID, userID)
+	if err != nil {
+		c.JSON(albumErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ctrl.present(album))
+}
+
+func (ctrl *AlbumController) Update(c *gin.Context) {
+	userID, albumID, ok := albumRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateAlbumRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	query, err := req.Query.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	album, err := ctrl.albumService.UpdateAlbum(c.Request.Context(), albumID, userID, req.Title, req.Description, query)
+	if err != nil {
+		c.JSON(albumErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ctrl.present(album))
+}
+
+func (ctrl *AlbumController) Delete(c *gin.Context) {
+	userID, albumID, ok := albumRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.albumService.DeleteAlbum(c.Request.Context(), albumID, userID); err != nil {
+		c.JSON(albumErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Album deleted successfully"})
+}
+
+// ListPhotos 按相册顺序分页列出图片（支持 q/tag/startDate/endDate 过滤）
+func (ctrl *AlbumController) ListPhotos(c *gin.Context) {
+	userID, albumID, ok := albumRequestIDs(c)
+	if !ok {
+		return
+	}
+	page, limit := pageQuery(c)
+	filter, err := photoFilterQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	photos, total, err := ctrl.albumService.ListAlbumPhotos(c.Request.Context(), albumID, userID, filter, page, limit)
+	if err != nil {
+		c.JSON(albumErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": ctrl.photoService.PresentPhotos(photos, renditionsQuery(c)...),
+		"meta": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+func (ctrl *AlbumController) AddPhotos(c *gin.Context) {
+	ctrl.updatePhotos(c, ctrl.albumService.AddPhotos)
+}
+
+func (ctrl *AlbumController) RemovePhotos(c *gin.Context) {
+	ctrl.updatePhotos(c, ctrl.albumService.RemovePhotos)
+}
+
+// ReorderPhotos 提交相册内全部图片的新顺序
+func (ctrl *AlbumController) ReorderPhotos(c *gin.Context) {
+	ctrl.updatePhotos(c, ctrl.albumService.ReorderPhotos)
+}
+
+// SetCover 设置封面（photoId 为 null 时恢复为第一张）
+func (ctrl *AlbumController) SetCover(c *gin.Context) {
+	userID, albumID, ok := albumRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	var req AlbumCoverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	var photoID *primitive.ObjectID
+	if req.PhotoID != nil {
+		id, err := primitive.ObjectIDFromHex(*req.PhotoID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo ID"})
+			return
+		}
+		photoID = &id
+	}
+
+	album, err := ctrl.albumService.SetCover(c.Request.Context(), albumID, userID, photoID)
+	if err != nil {
+		c.JSON(albumErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ctrl.present(album))
+}
+
+// AddMember 按邮箱邀请成员（仅所有者）；已是成员时更新角色
+func (ctrl *AlbumController) AddMember(c *gin.Context) {
+	userID, albumID, ok := albumRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	var req AddAlbumMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	album, err := ctrl.albumService.AddMember(c.Request.Context(), albumID, userID, req.Email, req.Role)
+	if err != nil {
+		c.JSON(albumErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ctrl.present(album))
+}
+
+// UpdateMember 修改成员角色（仅所有者）
+func (ctrl *AlbumController) UpdateMember(c *gin.Context) {
+	userID, albumID, ok := albumRequestIDs(c)
+	if !ok {
+		return
+	}
+	memberID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req UpdateAlbumMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	album, err := ctrl.albumService.UpdateMemberRole(c.Request.Context(), albumID, userID, memberID, req.Role)
+	if err != nil {
+		c.JSON(albumErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ctrl.present(album))
+}
+
+// RemoveMember 移除成员（所有者），或成员退出共享相册（userId 为自己）
+func (ctrl *AlbumController) RemoveMember(c *gin.Context) {
+	userID, albumID, ok := albumRequestIDs(c)
+	if !ok {
+		return
+	}
+	memberID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := ctrl.albumService.RemoveMember(c.Request.Context(), albumID, userID, memberID); err != nil {
+		c.JSON(albumErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+type albumPhotosFunc func(ctx context.Context, albumID, userID primitive.ObjectID, photoIDs []primitive.ObjectID) (*service.AlbumView, error)
+
+// updatePhotos 添加/移除/排序共用的请求解析：{"photoIds": ["..."]}
+func (ctrl *AlbumController) updatePhotos(c *gin.Context, fn albumPhotosFunc) {
+	userID, albumID, ok := albumRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	var req AlbumPhotosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	photoIDs, err := parseObjectIDs(req.PhotoIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	album, err := fn(c.Request.Context(), albumID, userID, photoIDs)
+	if err != nil {
+		c.JSON(albumErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ctrl.present(album))
+}
+
+// present 将相册封面转换为带签名的访问地址
+func (ctrl *AlbumController) present(album *service.AlbumView) *service.AlbumView {
+	if album != nil && album.Cover != nil {
+		album.Cover = ctrl.photoService.PresentPhoto(album.Cover)
+	}
+	return album
+}
+
+func albumRequestIDs(c *gin.Context) (primitive.ObjectID, primitive.ObjectID, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	albumID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid album ID"})
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return userID, albumID, true
+}
+
+func parseObjectIDs(values []string) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, v := range values {
+		id, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid photo ID: %s", v)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func albumErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAlbumNotFound),
+		errors.Is(err, service.ErrAlbumUserNotFound),
+		errors.Is(err, service.ErrAlbumMemberNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrAlbumForbidden),
+		errors.Is(err, service.ErrAlbumPermissionDenied):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrAlbumTitleRequired),
+		errors.Is(err, service.ErrAlbumInvalidPhotos),
+		errors.Is(err, service.ErrPhotoNotInAlbum),
+		errors.Is(err, service.ErrAlbumTooManyPhotos),
+		errors.Is(err, service.ErrInvalidSmartQuery),
+		errors.Is(err, service.ErrSmartAlbumReadOnly),
+		errors.Is(err, service.ErrInvalidAlbumRole),
+		errors.Is(err, service.ErrAlbumMemberIsOwner):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrAlbumOrderMismatch):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
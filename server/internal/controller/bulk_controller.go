@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"photoms/internal/service"
+	"photoms/pkg/ai"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BulkController 批量操作图片（标签、描述、移动到相册、AI 标注、移入回收站）
+type BulkController struct {
+	bulkService *service.BulkService
+}
+
+func NewBulkController(bulkService *service.BulkService) *BulkController {
+	return &BulkController{bulkService: bulkService}
+}
+
+// BulkRequest ids 与 filter 二选一；filter 与智能相册查询条件格式相同
+type BulkRequest struct {
+	IDs        []string              `json:"ids"`
+	Filter     *SmartQueryRequest    `json:"filter"`
+	Operations BulkOperationsRequest `json:"operations"`
+}
+
+type BulkOperationsRequest struct {
+	AddTags        []string `json:"addTags"`
+	RemoveTags     []string `json:"removeTags"`
+	SetDescription *string  `json:"setDescription"`
+	MoveToAlbum    string   `json:"moveToAlbum"` // 相册 ID，图片移动到该相册（并从自己可编辑的其他手动相册中移除）
+	AITags         bool     `json:"aiTags"`
+	Trash          bool     `json:"trash"`
+}
+
+// Apply POST /photos/bulk 执行批量操作，返回每张图片的处理结果
+func (ctrl *BulkController) Apply(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req BulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	ids, err := parseObjectIDs(req.IDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter, err := req.Filter.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ops := service.BulkOperations{
+		AddTags:     req.Operations.AddTags,
+		RemoveTags:  req.Operations.RemoveTags,
+		Description: req.Operations.SetDescription,
+		AITags:      req.Operations.AITags,
+		Trash:       req.Operations.Trash,
+	}
+	if req.Operations.MoveToAlbum != "" {
+		albumID, err := primitive.ObjectIDFromHex(req.Operations.MoveToAlbum)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid album ID"})
+			return
+		}
+		ops.AlbumID = &albumID
+	}
+
+	results, err := ctrl.bulkService.Apply(c.Request.Context(), userID, service.BulkTarget{PhotoIDs: ids, Filter: filter}, ops)
+	if err != nil {
+		c.JSON(bulkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	counts := map[string]int{}
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": results,
+		"meta": gin.H{
+			"total":     len(results),
+			"succeeded": counts[service.BulkStatusSucceeded],
+			"partial":   counts[service.BulkStatusPartial],
+			"failed":    counts[service.BulkStatusFailed],
+		},
+	})
+}
+
+func bulkErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrBulkNoTarget),
+		errors.Is(err, service.ErrBulkNoOperation),
+		errors.Is(err, service.ErrBulkTooManyPhotos),
+		errors.Is(err, service.ErrInvalidBulkOperation),
+		errors.Is(err, service.ErrInvalidSmartQuery),
+		errors.Is(err, ai.ErrDisabled),
+		errors.Is(err, ai.ErrNotConfigured):
+		return http.StatusBadRequest
+	default:
+		return albumErrorStatus(err)
+	}
+}
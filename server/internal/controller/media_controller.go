@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"photoms/internal/service"
+	"photoms/pkg/storage"
+	"photoms/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MediaController 提供私有媒体文件访问：通过 HMAC 签名且会过期的 URL 读取存储中的图片文件
+type MediaController struct {
+	photoService *service.PhotoService
+}
+
+func NewMediaController(photoService *service.PhotoService) *MediaController {
+	return &MediaController{photoService: photoService}
+}
+
+// Serve GET /media/*key?exp=&sig=
+func (ctrl *MediaController) Serve(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	exp := c.Query("exp")
+	sig := c.Query("sig")
+
+	obj, err := ctrl.photoService.OpenSignedMedia(c.Request.Context(), key, exp, sig)
+	if err != nil {
+		c.JSON(mediaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	maxAge := int64(0)
+	if unix, err := strconv.ParseInt(exp, 10, 64); err == nil {
+		maxAge = unix - time.Now().Unix()
+	}
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+	writeMedia(c, obj)
+}
+
+// writeMedia 输出媒体内容；底层 Reader 支持 Seek 时使用 http.ServeContent 以支持 Range/条件请求
+func writeMedia(c *gin.Context, obj *service.MediaObject) {
+	defer obj.Reader.Close()
+
+	c.Header("X-Content-Type-Options", "nosniff")
+	if obj.Info.ContentType != "" {
+		c.Header("Content-Type", obj.Info.ContentType)
+	}
+
+	if rs, ok := obj.Reader.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, obj.Info.Key, obj.Info.ModTime, rs)
+		return
+	}
+
+	contentType := obj.Info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.DataFromReader(http.StatusOK, obj.Info.Size, contentType, obj.Reader, nil)
+}
+
+func mediaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrMediaSignatureInvalid), errors.Is(err, utils.ErrMediaURLExpired):
+		return http.StatusForbidden
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, storage.ErrInvalidKey):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrUnknownVariant):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"photoms/internal/models"
+	"photoms/internal/repository"
 	"photoms/internal/service"
 	"photoms/pkg/ai"
+	"photoms/pkg/utils"
 	"strconv"
 	"strings"
 	"time"
@@ -24,8 +27,18 @@ func NewPhotoController(photoService *service.PhotoService) *PhotoController {
 }
 
 func (ctrl *PhotoController) Upload(c *gin.Context) {
+	if max := ctrl.photoService.MaxUploadBytes(); max > 0 {
+		// 额外预留 1MB 给 multipart 边界与其他表单字段
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, max+1<<20)
+	}
+
 	file, err := c.FormFile("file")
 	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": service.ErrFileTooLarge.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
 		return
 	}
@@ -41,15 +54,166 @@ func (ctrl *PhotoController) Upload(c *gin.Context) {
 
 	photo, err := ctrl.photoService.UploadPhoto(c.Request.Context(), userID, file)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(uploadRejectionStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ctrl.photoService.PresentPhoto(photo))
+}
+
+// BatchUpload 批量上传（multipart 字段 files，可多个），返回每个文件的处理结果
+func (ctrl *PhotoController) BatchUpload(c *gin.Context) {
+	maxBytes, maxFiles := ctrl.photoService.MaxUploadBytes(), ctrl.photoService.MaxBatchFiles()
+	if maxBytes > 0 && maxFiles > 0 {
+		// 每个文件额外预留 64KB 给 multipart 边界与文件头，整体再预留 1MB 给其他表单字段
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(maxFiles)*(maxBytes+64<<10)+1<<20)
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": service.ErrFileTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid multipart form"})
+		return
+	}
+	files := form.File["files"]
+	if len(files) == 0 {
+		files = form.File["file"]
+	}
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
 		return
 	}
+	if maxFiles > 0 && len(files) > maxFiles {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Too many files: %d (max %d)", len(files), maxFiles)})
+		return
+	}
+
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID, _ := primitive.ObjectIDFromHex(userIDStr.(string))
+
+	results := ctrl.photoService.UploadPhotos(c.Request.Context(), userID, files)
+	for i := range results {
+		results[i].Photo = ctrl.photoService.PresentPhoto(results[i].Photo)
+	}
+
+	counts := map[string]int{
+		service.UploadStatusCreated:      0,
+		service.UploadStatusDeduplicated: 0,
+		service.UploadStatusRejected:     0,
+		service.UploadStatusFailed:       0,
+	}
+	for _, r := range results {
+		counts[r.Status]++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": results,
+		"meta": gin.H{
+			"total":        len(results),
+			"created":      counts[service.UploadStatusCreated],
+			"deduplicated": counts[service.UploadStatusDeduplicated],
+			"rejected":     counts[service.UploadStatusRejected],
+			"failed":       counts[service.UploadStatusFailed],
+		},
+	})
+}
 
-	c.JSON(http.StatusOK, photo)
+// uploadRejectionStatus 将上传内容校验错误映射为对应的 4xx 状态码，其余错误返回 500
+func uploadRejectionStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrUnsupportedImageType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrImageTooLarge), errors.Is(err, service.ErrInvalidImage):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 func (ctrl *PhotoController) List(c *gin.Context) {
-	// 1. 获取分页参数
+	// 1. 获取分页与过滤参数
+	page, limit := pageQuery(c)
+	filter, err := photoFilterQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctrl.respondPhotoList(c, filter, page, limit)
+}
+
+// respondPhotoList 按 filter 查询当前用户的图片并返回列表（支持 cursor 游标分页）
+func (ctrl *PhotoController) respondPhotoList(c *gin.Context, filter repository.PhotoFilter, page, limit int64) {
+	// 游标分页：cursor 来自上一页的 meta.nextCursor，提供时忽略 page
+	if value := strings.TrimSpace(c.Query("cursor")); value != "" {
+		after, err := repository.DecodePhotoCursor(value, filter)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter.After = after
+	}
+
+	// 2. 获取当前用户 ID
+	userIDStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIDStr.(string))
+
+	// 3. 调用 Service 获取数据
+	photos, total, err := ctrl.photoService.ListPhotos(c.Request.Context(), userID, filter, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta := gin.H{
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	}
+	if int64(len(photos)) == limit {
+		meta["nextCursor"] = repository.NewPhotoCursor(filter, photos[len(photos)-1]).Encode()
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": ctrl.photoService.PresentPhotos(photos, renditionsQuery(c)...),
+		"meta": meta,
+	})
+}
+
+// Facets 按与图片列表相同的过滤参数统计标签（按来源分组）、相机、镜头与拍摄年月的图片数；
+// limit 为标签/相机/镜头各返回的最大项数（1-100，默认 20）
+func (ctrl *PhotoController) Facets(c *gin.Context) {
+	_, limit := pageQuery(c)
+	filter, err := photoFilterQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	facets, err := ctrl.photoService.PhotoFacets(c.Request.Context(), userID, filter, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, facets)
+}
+
+// pageQuery 解析分页参数（page 从 1 开始，limit 1-100，默认 20）
+func pageQuery(c *gin.Context) (int64, int64) {
 	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
 	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "20"), 10, 64)
 	if page < 1 {
@@ -61,46 +225,235 @@ func (ctrl *PhotoController) List(c *gin.Context) {
 	if limit > 100 {
 		limit = 100
 	}
+	return page, limit
+}
 
-	// 过滤参数
-	q := strings.TrimSpace(c.Query("q"))
-	tag := strings.TrimSpace(c.Query("tag"))
+// photoFilterQuery 解析图片列表的过滤与排序参数（q 检索语句、tag/startDate/endDate/dateField、EXIF 条件、
+// minRating/favorite/colorLabel、sort/order），图片列表与相册内列表共用
+func photoFilterQuery(c *gin.Context) (repository.PhotoFilter, error) {
+	filter := repository.PhotoFilter{
+		Q:   strings.TrimSpace(c.Query("q")),
+		Tag: strings.TrimSpace(c.Query("tag")),
+	}
 
 	startDate, err := parseDateQuery(c.Query("startDate"), false)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+		return filter, err
 	}
 	endDate, err := parseDateQuery(c.Query("endDate"), true)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+		return filter, err
+	}
+	filter.StartDate = startDate
+	filter.EndDate = endDate
+	switch dateField := strings.TrimSpace(c.Query("dateField")); dateField {
+	case "", repository.DateFieldUploaded, repository.DateFieldTaken:
+		filter.DateField = dateField
+	default:
+		return filter, fmt.Errorf("invalid dateField: must be uploaded or taken")
 	}
 
-	// 2. 获取当前用户 ID
-	userIDStr, _ := c.Get("userId")
+	if err := tagFilterQuery(c, &filter); err != nil {
+		return filter, err
+	}
+	if err := exifFilterQuery(c, &filter); err != nil {
+		return filter, err
+	}
+
+	if value := strings.TrimSpace(c.Query("minRating")); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > 5 {
+			return filter, fmt.Errorf("invalid minRating: must be an integer between 0 and 5")
+		}
+		filter.MinRating = &n
+	}
+	if value := strings.TrimSpace(c.Query("favorite")); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid favorite: must be true or false")
+		}
+		filter.Favorite = &b
+	}
+	if value := c.Query("colorLabel"); value != "" {
+		label, err := service.NormalizeColorLabelFilter(value)
+		if err != nil {
+			return filter, err
+		}
+		filter.ColorLabel = label
+	}
+
+	// q 可以是检索语句（如 tag:狗 iso:>1600 -tag:截图），其中的条件覆盖同类查询参数，其余的词作为关键词
+	if err := repository.ParsePhotoQuery(filter.Q, &filter); err != nil {
+		return filter, err
+	}
+
+	filter.Sort = strings.TrimSpace(c.Query("sort"))
+	filter.Order = strings.ToLower(strings.TrimSpace(c.Query("order")))
+	if !repository.IsValidPhotoSort(filter.Sort, filter.Order) {
+		return filter, fmt.Errorf("invalid sort: must be createdAt|takenAt|size|title|rating|relevance with order asc|desc")
+	}
+	return filter, nil
+}
+
+// tagFilterQuery 解析标签过滤参数：tags=a,b 与 tagMode=all|any、excludeTags=a,b、
+// tagSource=USER|AI、minTagScore=0~1（AI 标签的最低置信度）
+func tagFilterQuery(c *gin.Context, filter *repository.PhotoFilter) error {
+	filter.Tags = listQuery(c, "tags")
+	filter.ExcludeTags = listQuery(c, "excludeTags")
+	if len(filter.Tags) > repository.MaxFilterTags || len(filter.ExcludeTags) > repository.MaxFilterTags {
+		return fmt.Errorf("invalid tags: at most %d tags", repository.MaxFilterTags)
+	}
+	switch mode := strings.ToLower(strings.TrimSpace(c.Query("tagMode"))); mode {
+	case "", repository.TagModeAll, repository.TagModeAny:
+		filter.TagMode = mode
+	default:
+		return fmt.Errorf("invalid tagMode: must be all or any")
+	}
+	switch source := strings.ToUpper(strings.TrimSpace(c.Query("tagSource"))); source {
+	case "", models.TagSourceUser, models.TagSourceAI:
+		filter.TagSource = source
+	default:
+		return fmt.Errorf("invalid tagSource: must be USER or AI")
+	}
+	if value := strings.TrimSpace(c.Query("minTagScore")); value != "" {
+		score, ok := utils.ParseFinite(value)
+		if !ok || score < 0 || score > 1 {
+			return fmt.Errorf("invalid minTagScore: must be a number between 0 and 1")
+		}
+		filter.MinTagScore = &score
+	}
+	return nil
+}
+
+// exifFilterQuery 解析 EXIF 过滤参数：make/model/lens 精确匹配，hasGps，
+// 以及 minIso/maxIso、minAperture/maxAperture、minFocalLength/maxFocalLength、minShutter/maxShutter 范围
+// （快门可写作 1/250 或秒数）
+func exifFilterQuery(c *gin.Context, filter *repository.PhotoFilter) error {
+	filter.Make = strings.TrimSpace(c.Query("make"))
+	filter.Model = strings.TrimSpace(c.Query("model"))
+	filter.Lens = strings.TrimSpace(c.Query("lens"))
+	if value := strings.TrimSpace(c.Query("hasGps")); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid hasGps: must be true or false")
+		}
+		filter.HasGPS = &b
+	}
+
+	var err error
+	if filter.ISO, err = rangeQuery(c, "Iso", parseNonNegative); err != nil {
+		return err
+	}
+	if filter.Aperture, err = rangeQuery(c, "Aperture", parseNonNegative); err != nil {
+		return err
+	}
+	if filter.FocalLength, err = rangeQuery(c, "FocalLength", parseNonNegative); err != nil {
+		return err
+	}
+	if filter.ExposureTime, err = rangeQuery(c, "Shutter", utils.ParseShutterSpeed); err != nil {
+		return err
+	}
+	return nil
+}
+
+// rangeQuery 解析 min<name>/max<name> 一对查询参数
+func rangeQuery(c *gin.Context, name string, parse func(string) (float64, bool)) (repository.NumberRange, error) {
+	var r repository.NumberRange
+	for _, bound := range []struct {
+		param string
+		dst   **float64
+	}{{"min" + name, &r.Min}, {"max" + name, &r.Max}} {
+		value := strings.TrimSpace(c.Query(bound.param))
+		if value == "" {
+			continue
+		}
+		n, ok := parse(value)
+		if !ok {
+			return r, fmt.Errorf("invalid %s: %s", bound.param, value)
+		}
+		*bound.dst = &n
+	}
+	if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+		return r, fmt.Errorf("invalid range: min%s must not be greater than max%s", name, name)
+	}
+	return r, nil
+}
+
+func parseNonNegative(value string) (float64, bool) {
+	n, ok := utils.ParseFinite(value)
+	return n, ok && n >= 0
+}
+
+// Duplicates 按感知哈希列出当前用户的近似重复图片分组（?threshold=0-16，汉明距离；page/limit 按组分页）
+func (ctrl *PhotoController) Duplicates(c *gin.Context) {
+	threshold := service.DefaultDuplicateThreshold
+	if value := strings.TrimSpace(c.Query("threshold")); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid threshold"})
+			return
+		}
+		threshold = n
+	}
+
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
 	userID, _ := primitive.ObjectIDFromHex(userIDStr.(string))
 
-	// 3. 调用 Service 获取数据
-	photos, total, err := ctrl.photoService.ListPhotos(c.Request.Context(), userID, page, limit, q, tag, startDate, endDate)
+	page, limit := pageQuery(c)
+	groups, total, err := ctrl.photoService.FindDuplicates(c.Request.Context(), userID, threshold, page, limit)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidDuplicateThreshold) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	if len(photos) == 0 {
-		photos = []*models.Photo{}
+	renditions := renditionsQuery(c)
+	photoCount := 0
+	for i := range groups {
+		groups[i].Photos = ctrl.photoService.PresentPhotos(groups[i].Photos, renditions...)
+		photoCount += len(groups[i].Photos)
 	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"data": photos,
+		"data": groups,
 		"meta": gin.H{
-			"total": total,
-			"page":  page,
-			"limit": limit,
+			"groups":    len(groups),
+			"photos":    photoCount,
+			"threshold": threshold,
+			"total":     total,
+			"page":      page,
+			"limit":     limit,
 		},
 	})
 }
 
+// renditionsQuery 解析 ?renditions=sq200,w1280，用于只返回需要的缩略图规格
+func renditionsQuery(c *gin.Context) []string {
+	return listQuery(c, "renditions")
+}
+
+// listQuery 解析逗号分隔的查询参数，忽略空项
+func listQuery(c *gin.Context, key string) []string {
+	value := strings.TrimSpace(c.Query(key))
+	if value == "" {
+		return nil
+	}
+	items := make([]string, 0, 4)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func parseDateQuery(value string, endOfDay bool) (*time.Time, error) {
 	value = strings.TrimSpace(value)
 	if value == "" {
@@ -113,7 +466,7 @@ func parseDateQuery(value string, endOfDay bool) (*time.Time, error) {
 
 	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
 		if endOfDay {
-			t = t.Add(24*time.Hour - time.Nanosecond)
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
 		}
 		return &t, nil
 	}
@@ -149,7 +502,102 @@ func (ctrl *PhotoController) GetByID(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, photo)
+	c.JSON(http.StatusOK, ctrl.photoService.PresentPhoto(photo, renditionsQuery(c)...))
+}
+
+// File 以登录身份读取自己图片的原图或缩略图（?variant=original|thumb|<规格名>）
+func (ctrl *PhotoController) File(c *gin.Context) {
+	photoID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo ID"})
+		return
+	}
+
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID, _ := primitive.ObjectIDFromHex(userIDStr.(string))
+
+	obj, err := ctrl.photoService.OpenPhotoFile(c.Request.Context(), photoID, userID, c.DefaultQuery("variant", "original"))
+	if err != nil {
+		if err.Error() == "unauthorized: photo belongs to another user" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: You don't have permission to access this photo"})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "photo not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Photo not found"})
+			return
+		}
+		c.JSON(mediaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	writeMedia(c, obj)
+}
+
+// Render 动态缩放/转码：GET /photos/:id/render?w=&h=&fit=contain|cover&format=webp|jpeg|png&q=
+func (ctrl *PhotoController) Render(c *gin.Context) {
+	photoID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo ID"})
+		return
+	}
+
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID, _ := primitive.ObjectIDFromHex(userIDStr.(string))
+
+	opts := service.RenderOptions{
+		Fit:    c.Query("fit"),
+		Format: c.Query("format"),
+	}
+	for name, dst := range map[string]*int{"w": &opts.Width, "h": &opts.Height, "q": &opts.Quality} {
+		value := strings.TrimSpace(c.Query(name))
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid %s: %s", name, value)})
+			return
+		}
+		*dst = n
+	}
+
+	rendered, err := ctrl.photoService.RenderPhoto(c.Request.Context(), photoID, userID, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRenderOptions):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "unauthorized: photo belongs to another user":
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: You don't have permission to access this photo"})
+		case strings.HasPrefix(err.Error(), "photo not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Photo not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	f, err := os.Open(rendered.Path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	// 输出只由图片内容与参数决定，可长期缓存；ETag 供 If-None-Match 返回 304
+	c.Header("ETag", rendered.ETag)
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Header("Content-Type", rendered.ContentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	http.ServeContent(c.Writer, c.Request, "", rendered.ModTime, f)
 }
 
 func (ctrl *PhotoController) Update(c *gin.Context) {
@@ -183,11 +631,23 @@ func (ctrl *PhotoController) Update(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: You don't have permission to update this photo"})
 			return
 		}
+		if strings.HasPrefix(err.Error(), "unauthorized:") {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "photo not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Photo not found"})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidPhotoUpdate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, photo)
+	c.JSON(http.StatusOK, ctrl.photoService.PresentPhoto(photo))
 }
 
 func (ctrl *PhotoController) Delete(c *gin.Context) {
@@ -207,18 +667,119 @@ func (ctrl *PhotoController) Delete(c *gin.Context) {
 	}
 	userID, _ := primitive.ObjectIDFromHex(userIDStr.(string))
 
-	// 3. 调用Service删除照片
+	// 3. 调用Service删除照片（移入回收站）
 	err = ctrl.photoService.DeletePhoto(c.Request.Context(), photoID, userID)
 	if err != nil {
 		if err.Error() == "unauthorized: photo belongs to another user" {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: You don't have permission to delete this photo"})
 			return
 		}
+		if strings.HasPrefix(err.Error(), "photo not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Photo not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Photo moved to trash"})
+}
+
+// Trash 分页列出回收站中的图片（最近删除的在前）
+func (ctrl *PhotoController) Trash(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	page, limit := pageQuery(c)
+
+	photos, total, err := ctrl.photoService.ListTrash(c.Request.Context(), userID, page, limit)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Photo deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{
+		"data": ctrl.photoService.PresentPhotos(photos, renditionsQuery(c)...),
+		"meta": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+// Restore 从回收站恢复图片
+func (ctrl *PhotoController) Restore(c *gin.Context) {
+	userID, photoID, ok := trashRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	photo, err := ctrl.photoService.RestorePhoto(c.Request.Context(), photoID, userID)
+	if err != nil {
+		c.JSON(trashErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ctrl.photoService.PresentPhoto(photo))
+}
+
+// Purge 立即彻底删除回收站中的一张图片
+func (ctrl *PhotoController) Purge(c *gin.Context) {
+	userID, photoID, ok := trashRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.photoService.PurgePhoto(c.Request.Context(), photoID, userID); err != nil {
+		c.JSON(trashErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Photo deleted permanently"})
+}
+
+// EmptyTrash 清空回收站
+func (ctrl *PhotoController) EmptyTrash(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	n, err := ctrl.photoService.EmptyTrash(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Trash emptied", "deleted": n})
+}
+
+func trashRequestIDs(c *gin.Context) (primitive.ObjectID, primitive.ObjectID, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	photoID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo ID"})
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return userID, photoID, true
+}
+
+func trashErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrPhotoNotInTrash):
+		return http.StatusConflict
+	case strings.HasPrefix(err.Error(), "unauthorized:"):
+		return http.StatusForbidden
+	case strings.HasPrefix(err.Error(), "photo not found"):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 // server/internal/controller/photo_controller.go 增加方法
@@ -267,7 +828,7 @@ func (ctrl *PhotoController) Edit(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, photo)
+	c.JSON(http.StatusOK, ctrl.photoService.PresentPhoto(photo))
 }
 
 func (ctrl *PhotoController) GenerateAITags(c *gin.Context) {
@@ -303,5 +864,5 @@ func (ctrl *PhotoController) GenerateAITags(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, photo)
+	c.JSON(http.StatusOK, ctrl.photoService.PresentPhoto(photo))
 }
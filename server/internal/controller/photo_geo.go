@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"photoms/internal/repository"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultNearRadius/maxNearRadius 附近检索的默认/最大半径（米）
+	defaultNearRadius = 1000
+	maxNearRadius     = 1000 * 1000
+	// maxClusterZoom 地图聚合支持的最大缩放级别
+	maxClusterZoom = 22
+)
+
+// Within 列出 bbox=west,south,east,north 范围内的图片（west > east 表示跨越 180° 经线），
+// 支持与图片列表相同的过滤、排序与分页参数
+func (ctrl *PhotoController) Within(c *gin.Context) {
+	page, limit := pageQuery(c)
+	filter, err := photoFilterQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.BBox, err = bboxQuery(c, true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctrl.respondPhotoList(c, filter, page, limit)
+}
+
+// Near 列出 lat/lng 为中心、radius 米（默认 1000，最大 1000 km）范围内的图片，由近到远排序，
+// 每张图片带 distance（米）；支持与图片列表相同的过滤参数与 page/limit 分页
+func (ctrl *PhotoController) Near(c *gin.Context) {
+	page, limit := pageQuery(c)
+	filter, err := photoFilterQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.Near, err = nearQuery(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	photos, total, err := ctrl.photoService.NearbyPhotos(c.Request.Context(), userID, filter, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": ctrl.photoService.PresentPhotos(photos, renditionsQuery(c)...),
+		"meta": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+// Clusters 按地图缩放级别 zoom（0-22）聚合带位置的图片，可用 bbox 限定为当前视野；
+// 支持与图片列表相同的过滤参数，每个网格附带一张封面图片（renditions 参数同图片列表）
+func (ctrl *PhotoController) Clusters(c *gin.Context) {
+	zoom, err := strconv.Atoi(strings.TrimSpace(c.Query("zoom")))
+	if err != nil || zoom < 0 || zoom > maxClusterZoom {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid zoom: must be an integer between 0 and %d", maxClusterZoom)})
+		return
+	}
+	filter, err := photoFilterQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.BBox, err = bboxQuery(c, false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	clusters, err := ctrl.photoService.PhotoClusters(c.Request.Context(), userID, filter, zoom)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	renditions := renditionsQuery(c)
+	for _, cluster := range clusters {
+		cluster.Photo = ctrl.photoService.PresentPhoto(cluster.Photo, renditions...)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": clusters, "zoom": zoom})
+}
+
+// bboxQuery 解析 bbox=west,south,east,north（经纬度，度）
+func bboxQuery(c *gin.Context, required bool) (*repository.BBox, error) {
+	value := strings.TrimSpace(c.Query("bbox"))
+	if value == "" {
+		if required {
+			return nil, fmt.Errorf("missing bbox: expected west,south,east,north")
+		}
+		return nil, nil
+	}
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("invalid bbox: expected west,south,east,north")
+	}
+	var v [4]float64
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bbox: %q is not a number", part)
+		}
+		v[i] = n
+	}
+	box := &repository.BBox{West: v[0], South: v[1], East: v[2], North: v[3]}
+	if !validLongitude(box.West) || !validLongitude(box.East) || box.West == box.East {
+		return nil, fmt.Errorf("invalid bbox: west and east must be different longitudes between -180 and 180")
+	}
+	if !validLatitude(box.South) || !validLatitude(box.North) || box.South >= box.North {
+		return nil, fmt.Errorf("invalid bbox: south must be less than north, both between -90 and 90")
+	}
+	return box, nil
+}
+
+// nearQuery 解析 lat/lng/radius（米）
+func nearQuery(c *gin.Context) (*repository.GeoCircle, error) {
+	lat, err := strconv.ParseFloat(strings.TrimSpace(c.Query("lat")), 64)
+	if err != nil || !validLatitude(lat) {
+		return nil, fmt.Errorf("invalid lat: must be a number between -90 and 90")
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(c.Query("lng")), 64)
+	if err != nil || !validLongitude(lng) {
+		return nil, fmt.Errorf("invalid lng: must be a number between -180 and 180")
+	}
+	radius := float64(defaultNearRadius)
+	if value := strings.TrimSpace(c.Query("radius")); value != "" {
+		radius, err = strconv.ParseFloat(value, 64)
+		if err != nil || radius <= 0 || radius > maxNearRadius {
+			return nil, fmt.Errorf("invalid radius: must be between 0 and %d meters", maxNearRadius)
+		}
+	}
+	return &repository.GeoCircle{Lat: lat, Lng: lng, Radius: radius}, nil
+}
+
+func validLatitude(v float64) bool {
+	return v >= -90 && v <= 90
+}
+
+func validLongitude(v float64) bool {
+	return v >= -180 && v <= 180
+}
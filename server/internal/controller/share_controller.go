@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"photoms/internal/service"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// sharePasswordHeader 访问带密码的分享链接时通过该请求头提交密码
+const sharePasswordHeader = "X-Share-Password"
+
+// ShareController 分享链接：所有者管理（需登录）与公开访问（/s/:token，无需登录）
+type ShareController struct {
+	shareService *service.ShareService
+}
+
+func NewShareController(shareService *service.ShareService) *ShareController {
+	return &ShareController{shareService: shareService}
+}
+
+type CreateShareRequest struct {
+	PhotoID       string `json:"photoId"`
+	AlbumID       string `json:"albumId"`
+	Password      string `json:"password"`
+	ExpiresAt     string `json:"expiresAt"` // RFC3339 或 2006-01-02（当天结束时过期）
+	AllowDownload bool   `json:"allowDownload"`
+}
+
+func (ctrl *ShareController) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req CreateShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	in := service.CreateShareInput{Password: req.Password, AllowDownload: req.AllowDownload}
+	if req.PhotoID != "" {
+		id, err := primitive.ObjectIDFromHex(req.PhotoID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo ID"})
+			return
+		}
+		in.PhotoID = &id
+	}
+	if req.AlbumID != "" {
+		id, err := primitive.ObjectIDFromHex(req.AlbumID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid album ID"})
+			return
+		}
+		in.AlbumID = &id
+	}
+	expiresAt, err := parseDateQuery(req.ExpiresAt, true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	in.ExpiresAt = expiresAt
+
+	share, err := ctrl.shareService.CreateShare(c.Request.Context(), userID, in)
+	if err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, share)
+}
+
+// List “我的分享”
+func (ctrl *ShareController) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	page, limit := pageQuery(c)
+
+	shares, total, err := ctrl.shareService.ListShares(c.Request.Context(), userID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": shares,
+		"meta": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+// Revoke 撤销分享链接
+func (ctrl *ShareController) Revoke(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	shareID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid share ID"})
+		return
+	}
+
+	if err := ctrl.shareService.RevokeShare(c.Request.Context(), shareID, userID); err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Share revoked successfully"})
+}
+
+// View GET /s/:token 公开访问分享内容（计入访问次数）
+func (ctrl *ShareController) View(c *gin.Context) {
+	content, err := ctrl.shareService.ViewShare(c.Request.Context(), c.Param("token"), c.GetHeader(sharePasswordHeader))
+	if err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, content)
+}
+
+// Photos GET /s/:token/photos 分页列出分享相册中的图片
+func (ctrl *ShareController) Photos(c *gin.Context) {
+	page, limit := pageQuery(c)
+
+	photos, total, err := ctrl.shareService.ListSharedPhotos(c.Request.Context(), c.Param("token"), c.GetHeader(sharePasswordHeader), page, limit)
+	if err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"data": photos,
+		"meta": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+// File GET /s/:token/file?photoId=&variant=original|thumb|<规格名> 读取分享中的图片文件，原图以附件形式下载
+func (ctrl *ShareController) File(c *gin.Context) {
+	var photoID *primitive.ObjectID
+	if value := strings.TrimSpace(c.Query("photoId")); value != "" {
+		id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo ID"})
+			return
+		}
+		photoID = &id
+	}
+	variant := c.DefaultQuery("variant", "original")
+
+	obj, err := ctrl.shareService.OpenSharedFile(c.Request.Context(), c.Param("token"), c.GetHeader(sharePasswordHeader), photoID, variant)
+	if err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if variant == "original" {
+		c.Header("Content-Disposition", "attachment")
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	writeMedia(c, obj)
+}
+
+func shareErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrShareNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrShareExpired):
+		return http.StatusGone
+	case errors.Is(err, service.ErrSharePasswordRequired):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrShareForbidden),
+		errors.Is(err, service.ErrSharePasswordInvalid),
+		errors.Is(err, service.ErrShareDownloadDisabled),
+		errors.Is(err, service.ErrPhotoNotInShare):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrInvalidShareTarget),
+		errors.Is(err, service.ErrInvalidShareExpiry),
+		errors.Is(err, service.ErrShareNotAlbum):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrAlbumNotFound), errors.Is(err, service.ErrAlbumForbidden):
+		return albumErrorStatus(err)
+	case strings.HasPrefix(err.Error(), "photo not found"):
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "unauthorized:"):
+		return http.StatusForbidden
+	default:
+		return mediaErrorStatus(err)
+	}
+}
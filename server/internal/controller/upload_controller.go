@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"photoms/internal/models"
+	"photoms/internal/service"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UploadController 断点续传接口（tus 风格）：
+//
+//	POST   /uploads              创建会话
+//	HEAD   /uploads/:id          查询进度（Upload-Offset）
+//	PATCH  /uploads/:id          从 Upload-Offset 处追加分块
+//	POST   /uploads/:id/complete 完成上传并导入图片库
+//	DELETE /uploads/:id          取消上传
+type UploadController struct {
+	uploadService *service.UploadService
+	photoService  *service.PhotoService
+}
+
+func NewUploadController(uploadService *service.UploadService, photoService *service.PhotoService) *UploadController {
+	return &UploadController{uploadService: uploadService, photoService: photoService}
+}
+
+type CreateUploadRequest struct {
+	FileName string `json:"fileName" binding:"required"`
+	Size     int64  `json:"size" binding:"required,gt=0"`
+	MimeType string `json:"mimeType"`
+}
+
+func (ctrl *UploadController) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req CreateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := ctrl.uploadService.CreateSession(c.Request.Context(), userID, req.FileName, req.MimeType, req.Size)
+	if err != nil {
+		if errors.Is(err, service.ErrFileTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.Header("Location", "/api/v1/uploads/"+session.ID.Hex())
+	c.JSON(http.StatusCreated, session)
+}
+
+func (ctrl *UploadController) Head(c *gin.Context) {
+	userID, sessionID, ok := uploadRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	session, err := ctrl.uploadService.GetSession(c.Request.Context(), sessionID, userID)
+	if err != nil {
+		c.Status(uploadErrorStatus(err))
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
+
+func (ctrl *UploadController) Patch(c *gin.Context) {
+	userID, sessionID, ok := uploadRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	contentType := strings.TrimSpace(strings.Split(c.GetHeader("Content-Type"), ";")[0])
+	if contentType != "application/offset+octet-stream" && contentType != "application/octet-stream" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/offset+octet-stream"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(strings.TrimSpace(c.GetHeader("Upload-Offset")), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or missing Upload-Offset header"})
+		return
+	}
+
+	newOffset, err := ctrl.uploadService.AppendChunk(c.Request.Context(), sessionID, userID, offset, c.Request.Body)
+	c.Header("Upload-Offset", strconv.FormatInt(newOffset, 10))
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (ctrl *UploadController) Complete(c *gin.Context) {
+	userID, sessionID, ok := uploadRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	photo, err := ctrl.uploadService.Complete(c.Request.Context(), sessionID, userID)
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ctrl.photoService.PresentPhoto(photo))
+}
+
+func (ctrl *UploadController) Delete(c *gin.Context) {
+	userID, sessionID, ok := uploadRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.uploadService.Abort(c.Request.Context(), sessionID, userID); err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func uploadRequestIDs(c *gin.Context) (primitive.ObjectID, primitive.ObjectID, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	sessionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid upload ID"})
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return userID, sessionID, true
+}
+
+func setUploadHeaders(c *gin.Context, session *models.UploadSession) {
+	c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(session.Size, 10))
+	c.Header("Upload-Expires", session.ExpiresAt.Time().UTC().Format(http.TimeFormat))
+}
+
+func uploadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrUploadSessionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrUploadSessionForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrUploadSessionExpired):
+		return http.StatusGone
+	case errors.Is(err, service.ErrUploadOffsetMismatch),
+		errors.Is(err, service.ErrUploadIncomplete),
+		errors.Is(err, service.ErrUploadSessionCompleted):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrUploadExceedsLength):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return uploadRejectionStatus(err)
+	}
+}
+
+// currentUserID 从 JWT 中间件写入的上下文中读取当前用户 ID
+func currentUserID(c *gin.Context) (primitive.ObjectID, bool) {
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		return primitive.NilObjectID, false
+	}
+	userID, err := primitive.ObjectIDFromHex(userIDStr.(string))
+	if err != nil {
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
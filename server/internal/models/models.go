@@ -1,6 +1,10 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type User struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -12,20 +16,56 @@ type User struct {
 }
 
 type Photo struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID      primitive.ObjectID `bson:"user_id" json:"userId"`
-	Title       string             `bson:"title" json:"title"`
-	Description string             `bson:"description" json:"description"`
-	FileName    string             `bson:"file_name" json:"fileName"`
-	Path        string             `bson:"path" json:"path"`
-	ThumbPath   string             `bson:"thumb_path" json:"thumbPath"`
-	Hash        string             `bson:"hash" json:"hash"`
-	Size        int64              `bson:"size" json:"size"`
-	MimeType    string             `bson:"mime_type" json:"mimeType"`
-	Exif        *ExifInfo          `bson:"exif,omitempty" json:"exif,omitempty"`
-	Tags        []Tag              `bson:"tags,omitempty" json:"tags"`
-	CreatedAt   primitive.DateTime `bson:"created_at" json:"createdAt"`
-	UpdatedAt   primitive.DateTime `bson:"updated_at" json:"updatedAt"`
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID   `bson:"user_id" json:"userId"`
+	Title       string               `bson:"title" json:"title"`
+	Description string               `bson:"description" json:"description"`
+	FileName    string               `bson:"file_name" json:"fileName"`
+	Path        string               `bson:"path" json:"path"`
+	ThumbPath   string               `bson:"thumb_path" json:"thumbPath"`
+	Hash        string               `bson:"hash" json:"hash"`
+	PHash       string               `bson:"phash,omitempty" json:"phash,omitempty"` // 感知哈希（dHash，16 位十六进制），用于近似重复检测
+	Size        int64                `bson:"size" json:"size"`
+	MimeType    string               `bson:"mime_type" json:"mimeType"`
+	Exif        *ExifInfo            `bson:"exif,omitempty" json:"exif,omitempty"`
+	Tags        []Tag                `bson:"tags,omitempty" json:"tags"`
+	Renditions  map[string]Rendition `bson:"renditions,omitempty" json:"renditions,omitempty"`
+	Rating      int                  `bson:"rating" json:"rating"`                              // 星级 0-5，0 表示未评分
+	Favorite    bool                 `bson:"favorite,omitempty" json:"favorite"`                // 收藏
+	ColorLabel  string               `bson:"color_label,omitempty" json:"colorLabel,omitempty"` // 颜色标签，见 ColorLabel* 常量
+	TakenAt     primitive.DateTime   `bson:"taken_at" json:"takenAt"`                           // 拍摄地墙上时间（以 UTC 表示，EXIF 缺失时为上传时间），用于排序与按拍摄日期过滤
+	DeletedAt   *primitive.DateTime  `bson:"deleted_at,omitempty" json:"deletedAt,omitempty"`   // 移入回收站的时间，为空表示未删除
+	Search      *SearchIndex         `bson:"search,omitempty" json:"-"`                         // 全文检索词，随标题/描述/标签更新
+	Location    *GeoPoint            `bson:"location,omitempty" json:"-"`                       // exif.gps 的 GeoJSON 形式，用于地理检索（2dsphere 索引）
+	Place       *Place               `bson:"place,omitempty" json:"place,omitempty"`            // 由 exif.gps 离线逆地理编码得到的地点
+	Score       float64              `bson:"-" json:"score,omitempty"`                          // 关键词检索的相关度（仅检索结果）
+	Distance    float64              `bson:"-" json:"distance,omitempty"`                       // 与检索中心的距离（米，仅附近检索结果）
+	Highlights  *SearchHighlights    `bson:"-" json:"highlights,omitempty"`                     // 关键词检索的高亮片段（仅检索结果）
+	CreatedAt   primitive.DateTime   `bson:"created_at" json:"createdAt"`
+	UpdatedAt   primitive.DateTime   `bson:"updated_at" json:"updatedAt"`
+}
+
+// SearchIndex 图片各文本字段的检索词（见 pkg/textsearch），Terms 为全部字段的并集
+type SearchIndex struct {
+	Title       []string `bson:"title,omitempty"`
+	Description []string `bson:"description,omitempty"`
+	Tags        []string `bson:"tags,omitempty"`
+	Terms       []string `bson:"terms,omitempty"`
+}
+
+// SearchHighlights 检索结果中用 <em></em> 标出匹配部分的文本（已做 HTML 转义）
+type SearchHighlights struct {
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// Rendition 预生成的缩略图规格（按名称索引，如 sq200/w400/w1280）
+type Rendition struct {
+	Path   string `bson:"path" json:"path"`
+	Width  int    `bson:"width" json:"width"`
+	Height int    `bson:"height" json:"height"`
+	Size   int64  `bson:"size" json:"size"`
 }
 
 type ExifInfo struct {
@@ -35,9 +75,28 @@ type ExifInfo struct {
 	ISO          int                 `bson:"iso,omitempty" json:"iso,omitempty"`
 	Aperture     float64             `bson:"aperture,omitempty" json:"aperture,omitempty"`
 	ShutterSpeed string              `bson:"shutter_speed,omitempty" json:"shutterSpeed,omitempty"`
+	ExposureTime float64             `bson:"exposure_time,omitempty" json:"exposureTime,omitempty"` // 曝光时间（秒），用于按快门范围过滤
 	FocalLength  float64             `bson:"focal_length,omitempty" json:"focalLength,omitempty"`
 	GPS          *GPSInfo            `bson:"gps,omitempty" json:"gps,omitempty"`
-	TakenAt      *primitive.DateTime `bson:"taken_at,omitempty" json:"takenAt,omitempty"`
+	TakenAt      *primitive.DateTime `bson:"taken_at,omitempty" json:"takenAt,omitempty"`        // 拍摄时刻；UTCOffset 为空时按服务器时区解释相机时间
+	UTCOffset    *int                `bson:"utc_offset,omitempty" json:"utcOffset,omitempty"`    // 拍摄地相对 UTC 的偏移（分钟），无法确定时为空
+	Orientation  int                 `bson:"orientation,omitempty" json:"orientation,omitempty"` // EXIF Orientation（1-8），像素处理时已按此旋转
+	// Make/Model/Lens 的小写形式，用于可走索引的不区分大小写精确匹配，由 SetMatchKeys 生成
+	MakeKey  string `bson:"make_key,omitempty" json:"-"`
+	ModelKey string `bson:"model_key,omitempty" json:"-"`
+	LensKey  string `bson:"lens_key,omitempty" json:"-"`
+}
+
+// ExifMatchKey 返回品牌/型号/镜头用于精确匹配的形式（去掉首尾空白并转小写）
+func ExifMatchKey(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// SetMatchKeys 根据 Make/Model/Lens 生成对应的匹配字段
+func (e *ExifInfo) SetMatchKeys() {
+	e.MakeKey = ExifMatchKey(e.Make)
+	e.ModelKey = ExifMatchKey(e.Model)
+	e.LensKey = ExifMatchKey(e.Lens)
 }
 
 type GPSInfo struct {
@@ -45,8 +104,143 @@ type GPSInfo struct {
 	Longitude float64 `bson:"longitude" json:"longitude"`
 }
 
+// GeoPoint GeoJSON 点，坐标顺序为 [经度, 纬度]
+type GeoPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// NewGeoPoint 由 GPS 信息生成 GeoJSON 点，坐标无效时返回 nil
+func NewGeoPoint(gps *GPSInfo) *GeoPoint {
+	if gps == nil || gps.Latitude < -90 || gps.Latitude > 90 || gps.Longitude < -180 || gps.Longitude > 180 {
+		return nil
+	}
+	return &GeoPoint{Type: "Point", Coordinates: []float64{gps.Longitude, gps.Latitude}}
+}
+
+// Place 地点（中文名，*En 为英文名）。City 为空表示附近没有收录的城市，只定位到省/国家
+type Place struct {
+	CountryCode string `bson:"country_code" json:"countryCode"` // ISO 3166-1 二位代码
+	Country     string `bson:"country" json:"country"`
+	CountryEn   string `bson:"country_en" json:"countryEn"`
+	Province    string `bson:"province,omitempty" json:"province,omitempty"`
+	ProvinceEn  string `bson:"province_en,omitempty" json:"provinceEn,omitempty"`
+	City        string `bson:"city,omitempty" json:"city,omitempty"`
+	CityEn      string `bson:"city_en,omitempty" json:"cityEn,omitempty"`
+}
+
 type Tag struct {
 	Name   string  `bson:"name" json:"name"`
 	Source string  `bson:"source" json:"source"` // "USER" or "AI"
 	Score  float64 `bson:"score,omitempty" json:"score,omitempty"`
 }
+
+// 标签来源
+const (
+	TagSourceUser = "USER"
+	TagSourceAI   = "AI"
+)
+
+// 颜色标签（与 Lightroom 一致），空字符串表示无标签
+const (
+	ColorLabelRed    = "red"
+	ColorLabelYellow = "yellow"
+	ColorLabelGreen  = "green"
+	ColorLabelBlue   = "blue"
+	ColorLabelPurple = "purple"
+)
+
+// NormalizeColorLabel 将颜色标签转为小写并去掉首尾空白，ok 表示是有效标签或空字符串
+func NormalizeColorLabel(label string) (string, bool) {
+	label = strings.ToLower(strings.TrimSpace(label))
+	switch label {
+	case "", ColorLabelRed, ColorLabelYellow, ColorLabelGreen, ColorLabelBlue, ColorLabelPurple:
+		return label, true
+	default:
+		return label, false
+	}
+}
+
+const (
+	AlbumTypeManual = "manual"
+	AlbumTypeSmart  = "smart"
+)
+
+// 共享相册的角色：viewer 只读；contributor 可添加自己的图片并给相册内图片打标签；
+// editor 还可移除图片、排序、设置封面与修改相册信息。owner 仅表示所有者，不能分配给成员。
+const (
+	AlbumRoleViewer      = "viewer"
+	AlbumRoleContributor = "contributor"
+	AlbumRoleEditor      = "editor"
+	AlbumRoleOwner       = "owner"
+)
+
+// Album 相册：PhotoIDs 的顺序即手动排序；未设置封面时使用第一张图片。
+// 智能相册（Type 为 smart）不保存图片列表，读取时按 Query 实时查询。
+type Album struct {
+	ID           primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	UserID       primitive.ObjectID   `bson:"user_id" json:"userId"`
+	Type         string               `bson:"type,omitempty" json:"type"` // "manual"（默认）或 "smart"
+	Title        string               `bson:"title" json:"title"`
+	Description  string               `bson:"description" json:"description"`
+	PhotoIDs     []primitive.ObjectID `bson:"photo_ids" json:"photoIds"`
+	CoverPhotoID *primitive.ObjectID  `bson:"cover_photo_id,omitempty" json:"coverPhotoId,omitempty"`
+	Query        *SmartQuery          `bson:"query,omitempty" json:"query,omitempty"`
+	Members      []AlbumMember        `bson:"members,omitempty" json:"members"`
+	CreatedAt    primitive.DateTime   `bson:"created_at" json:"createdAt"`
+	UpdatedAt    primitive.DateTime   `bson:"updated_at" json:"updatedAt"`
+}
+
+// AlbumMember 共享相册成员
+type AlbumMember struct {
+	UserID   primitive.ObjectID `bson:"user_id" json:"userId"`
+	Username string             `bson:"username" json:"username"`
+	Role     string             `bson:"role" json:"role"`
+	AddedAt  primitive.DateTime `bson:"added_at" json:"addedAt"`
+}
+
+// SmartQuery 智能相册保存的查询条件，零值字段不参与过滤
+type SmartQuery struct {
+	Q          string              `bson:"q,omitempty" json:"q,omitempty"`
+	Tags       []string            `bson:"tags,omitempty" json:"tags,omitempty"` // 需同时包含
+	StartDate  *primitive.DateTime `bson:"start_date,omitempty" json:"startDate,omitempty"`
+	EndDate    *primitive.DateTime `bson:"end_date,omitempty" json:"endDate,omitempty"`
+	DateField  string              `bson:"date_field,omitempty" json:"dateField,omitempty"` // "uploaded"（默认）或 "taken"
+	Make       string              `bson:"make,omitempty" json:"make,omitempty"`
+	Model      string              `bson:"model,omitempty" json:"model,omitempty"`
+	HasGPS     *bool               `bson:"has_gps,omitempty" json:"hasGps,omitempty"`
+	MinRating  *int                `bson:"min_rating,omitempty" json:"minRating,omitempty"` // 0-5
+	Favorite   *bool               `bson:"favorite,omitempty" json:"favorite,omitempty"`
+	ColorLabel string              `bson:"color_label,omitempty" json:"colorLabel,omitempty"`
+}
+
+// Share 公开分享链接：持有随机 Token 的任何人都可以查看一张图片或一个相册，无需登录
+type Share struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID        primitive.ObjectID  `bson:"user_id" json:"userId"`
+	Token         string              `bson:"token" json:"token"`
+	PhotoID       *primitive.ObjectID `bson:"photo_id,omitempty" json:"photoId,omitempty"`
+	AlbumID       *primitive.ObjectID `bson:"album_id,omitempty" json:"albumId,omitempty"`
+	PasswordHash  string              `bson:"password_hash,omitempty" json:"-"` // bcrypt，为空表示无需密码
+	AllowDownload bool                `bson:"allow_download" json:"allowDownload"`
+	ExpiresAt     *primitive.DateTime `bson:"expires_at,omitempty" json:"expiresAt,omitempty"`
+	Views         int64               `bson:"views" json:"views"`
+	LastViewedAt  *primitive.DateTime `bson:"last_viewed_at,omitempty" json:"lastViewedAt,omitempty"`
+	CreatedAt     primitive.DateTime  `bson:"created_at" json:"createdAt"`
+	UpdatedAt     primitive.DateTime  `bson:"updated_at" json:"updatedAt"`
+}
+
+// UploadSession 断点续传会话（数据暂存在 UploadSessionDir，完成后导入图片库）
+type UploadSession struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID  `bson:"user_id" json:"userId"`
+	FileName  string              `bson:"file_name" json:"fileName"`
+	MimeType  string              `bson:"mime_type" json:"mimeType"`
+	Size      int64               `bson:"size" json:"size"`
+	Offset    int64               `bson:"offset" json:"offset"`
+	Status    string              `bson:"status" json:"status"` // "UPLOADING" or "COMPLETED"
+	PhotoID   *primitive.ObjectID `bson:"photo_id,omitempty" json:"photoId,omitempty"`
+	ExpiresAt primitive.DateTime  `bson:"expires_at" json:"expiresAt"`
+	CreatedAt primitive.DateTime  `bson:"created_at" json:"createdAt"`
+	UpdatedAt primitive.DateTime  `bson:"updated_at" json:"updatedAt"`
+}
@@ -0,0 +1,293 @@
+package repository
+
+import (
+	"context"
+	"photoms/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AlbumRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAlbumRepository(db *mongo.Database) *AlbumRepository {
+	return &AlbumRepository{
+		collection: db.Collection("albums"),
+	}
+}
+
+func (r *AlbumRepository) Create(ctx context.Context, album *models.Album) error {
+	now := primitive.NewDateTimeFromTime(time.Now())
+	album.CreatedAt = now
+	album.UpdatedAt = album.CreatedAt
+	if album.PhotoIDs == nil {
+		album.PhotoIDs = []primitive.ObjectID{}
+	}
+
+	result, err := r.collection.InsertOne(ctx, album)
+	if err != nil {
+		return err
+	}
+
+	album.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *AlbumRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Album, error) {
+	var album models.Album
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&album)
+	if err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
+
+// FindByUserID 分页查询用户的相册（最近更新的在前），albumType 为空时返回全部类型
+func (r *AlbumRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID, albumType string, page, limit int64) ([]*models.Album, int64, error) {
+	filter := bson.M{"user_id": userID}
+	switch albumType {
+	case models.AlbumTypeSmart:
+		filter["type"] = models.AlbumTypeSmart
+	case models.AlbumTypeManual:
+		// 早期创建的相册没有 type 字段
+		filter["type"] = bson.M{"$ne": models.AlbumTypeSmart}
+	}
+	opts := options.Find().
+		SetSkip((page - 1) * limit).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "updated_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var albums []*models.Album
+	if err = cursor.All(ctx, &albums); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return albums, total, nil
+}
+
+// FindSharedWith 分页查询以成员身份共享给用户的相册（最近更新的在前）
+func (r *AlbumRepository) FindSharedWith(ctx context.Context, userID primitive.ObjectID, page, limit int64) ([]*models.Album, int64, error) {
+	filter := bson.M{"members.user_id": userID}
+	opts := options.Find().
+		SetSkip((page - 1) * limit).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "updated_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var albums []*models.Album
+	if err = cursor.All(ctx, &albums); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return albums, total, nil
+}
+
+// FindAccessibleWithPhoto 查询包含该图片、且用户为所有者或成员的手动相册
+func (r *AlbumRepository) FindAccessibleWithPhoto(ctx context.Context, photoID, userID primitive.ObjectID) ([]*models.Album, error) {
+	filter := bson.M{
+		"photo_ids": photoID,
+		"$or": bson.A{
+			bson.M{"user_id": userID},
+			bson.M{"members.user_id": userID},
+		},
+	}
+	return r.find(ctx, filter)
+}
+
+// FindAccessibleWithPhotos 查询包含其中任一图片、且用户为所有者或成员的相册
+func (r *AlbumRepository) FindAccessibleWithPhotos(ctx context.Context, photoIDs []primitive.ObjectID, userID primitive.ObjectID) ([]*models.Album, error) {
+	filter := bson.M{
+		"photo_ids": bson.M{"$in": photoIDs},
+		"$or": bson.A{
+			bson.M{"user_id": userID},
+			bson.M{"members.user_id": userID},
+		},
+	}
+	return r.find(ctx, filter)
+}
+
+// FindSmartSharedWith 查询 ownerID 的智能相册中共享给 userID 的那些
+func (r *AlbumRepository) FindSmartSharedWith(ctx context.Context, ownerID, userID primitive.ObjectID) ([]*models.Album, error) {
+	filter := bson.M{
+		"user_id":         ownerID,
+		"type":            models.AlbumTypeSmart,
+		"members.user_id": userID,
+	}
+	return r.find(ctx, filter)
+}
+
+func (r *AlbumRepository) find(ctx context.Context, filter bson.M) ([]*models.Album, error) {
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var albums []*models.Album
+	if err = cursor.All(ctx, &albums); err != nil {
+		return nil, err
+	}
+	return albums, nil
+}
+
+func (r *AlbumRepository) Update(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	update["updated_at"] = primitive.NewDateTimeFromTime(time.Now())
+
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": update},
+	)
+	return err
+}
+
+func (r *AlbumRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// AddPhotos 将图片追加到相册末尾（已在相册中的图片保持原位置）
+func (r *AlbumRepository) AddPhotos(ctx context.Context, id primitive.ObjectID, photoIDs []primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$addToSet": bson.M{"photo_ids": bson.M{"$each": photoIDs}},
+			"$set":      bson.M{"updated_at": primitive.NewDateTimeFromTime(time.Now())},
+		},
+	)
+	return err
+}
+
+// RemovePhotos 从相册移除图片；若封面被移除则清空封面
+func (r *AlbumRepository) RemovePhotos(ctx context.Context, id primitive.ObjectID, photoIDs []primitive.ObjectID) error {
+	now := primitive.NewDateTimeFromTime(time.Now())
+	if _, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$pull": bson.M{"photo_ids": bson.M{"$in": photoIDs}},
+			"$set":  bson.M{"updated_at": now},
+		},
+	); err != nil {
+		return err
+	}
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "cover_photo_id": bson.M{"$in": photoIDs}},
+		bson.M{"$unset": bson.M{"cover_photo_id": ""}},
+	)
+	return err
+}
+
+// ReorderPhotos 仅当相册图片集合未被并发修改（数量一致且包含全部图片）时替换排序
+func (r *AlbumRepository) ReorderPhotos(ctx context.Context, id primitive.ObjectID, photoIDs []primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"_id":       id,
+			"photo_ids": bson.M{"$size": len(photoIDs), "$all": photoIDs},
+		},
+		bson.M{"$set": bson.M{
+			"photo_ids":  photoIDs,
+			"updated_at": primitive.NewDateTimeFromTime(time.Now()),
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// RemovePhotoFromAll 从所有相册中移除某张图片（删除图片时调用）
+func (r *AlbumRepository) RemovePhotoFromAll(ctx context.Context, photoID primitive.ObjectID) error {
+	now := primitive.NewDateTimeFromTime(time.Now())
+	if _, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"photo_ids": photoID},
+		bson.M{
+			"$pull": bson.M{"photo_ids": photoID},
+			"$set":  bson.M{"updated_at": now},
+		},
+	); err != nil {
+		return err
+	}
+	_, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"cover_photo_id": photoID},
+		bson.M{"$unset": bson.M{"cover_photo_id": ""}},
+	)
+	return err
+}
+
+// AddMember 添加成员；用户已是成员时返回 false（不修改）
+func (r *AlbumRepository) AddMember(ctx context.Context, id primitive.ObjectID, member models.AlbumMember) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "members.user_id": bson.M{"$ne": member.UserID}},
+		bson.M{
+			"$push": bson.M{"members": member},
+			"$set":  bson.M{"updated_at": primitive.NewDateTimeFromTime(time.Now())},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// SetMemberRole 修改成员角色；用户不是成员时返回 false
+func (r *AlbumRepository) SetMemberRole(ctx context.Context, id, userID primitive.ObjectID, role string) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "members.user_id": userID},
+		bson.M{"$set": bson.M{
+			"members.$.role": role,
+			"updated_at":     primitive.NewDateTimeFromTime(time.Now()),
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// RemoveMember 移除成员；用户不是成员时返回 false
+func (r *AlbumRepository) RemoveMember(ctx context.Context, id, userID primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "members.user_id": userID},
+		bson.M{
+			"$pull": bson.M{"members": bson.M{"user_id": userID}},
+			"$set":  bson.M{"updated_at": primitive.NewDateTimeFromTime(time.Now())},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"photoms/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidCursor 游标无法解析，或与当前的排序方式不一致
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// 图片列表的排序字段（接口参数名）
+const (
+	PhotoSortCreatedAt = "createdAt"
+	PhotoSortTakenAt   = "takenAt"
+	PhotoSortSize      = "size"
+	PhotoSortTitle     = "title"
+	PhotoSortRating    = "rating"
+	// PhotoSortRelevance 按关键词相关度排序，仅在有关键词（Q）时有效，也是此时的默认排序
+	PhotoSortRelevance = "relevance"
+)
+
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// photoSortFields 排序字段对应的数据库字段
+var photoSortFields = map[string]string{
+	PhotoSortCreatedAt: "created_at",
+	PhotoSortTakenAt:   "taken_at",
+	PhotoSortSize:      "size",
+	PhotoSortTitle:     "title",
+	PhotoSortRating:    "rating",
+}
+
+// relevanceField 检索时由聚合计算出的相关度字段
+const relevanceField = "_score"
+
+// IsValidPhotoSort 排序字段（空表示默认）与方向（空表示该字段的默认方向）是否有效
+func IsValidPhotoSort(sort, order string) bool {
+	if _, ok := photoSortFields[sort]; sort != "" && sort != PhotoSortRelevance && !ok {
+		return false
+	}
+	return order == "" || order == SortAsc || order == SortDesc
+}
+
+// PhotoCursor keyset 分页位置：上一页最后一张图片的排序值与 ID。
+// 排序值相同时按 _id 决定先后，因此翻页过程中插入/删除图片不会导致重复或遗漏。
+type PhotoCursor struct {
+	Sort  string
+	Order string
+	Value interface{}
+	ID    primitive.ObjectID
+}
+
+type cursorPayload struct {
+	Sort  string          `json:"s"`
+	Order string          `json:"o"`
+	Value json.RawMessage `json:"v"`
+	ID    string          `json:"id"`
+}
+
+// NewPhotoCursor 返回 photo 之后一页的游标，f 为本次查询的条件
+func NewPhotoCursor(f PhotoFilter, photo *models.Photo) *PhotoCursor {
+	sort, order := f.sortSpec()
+	c := &PhotoCursor{Sort: sort, Order: order, ID: photo.ID}
+	switch sort {
+	case PhotoSortTakenAt:
+		c.Value = int64(photo.TakenAt)
+	case PhotoSortSize:
+		c.Value = photo.Size
+	case PhotoSortTitle:
+		c.Value = photo.Title
+	case PhotoSortRating:
+		c.Value = int64(photo.Rating)
+	case PhotoSortRelevance:
+		c.Value = photo.Score
+	default:
+		c.Value = int64(photo.CreatedAt)
+	}
+	return c
+}
+
+// Encode 编码为不透明的 URL 安全字符串
+func (c *PhotoCursor) Encode() string {
+	value, _ := json.Marshal(c.Value)
+	b, _ := json.Marshal(cursorPayload{Sort: c.Sort, Order: c.Order, Value: value, ID: c.ID.Hex()})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodePhotoCursor 解析游标，并校验与 f 的排序方式一致
+func DecodePhotoCursor(s string, f PhotoFilter) (*PhotoCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var p cursorPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(p.ID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	sort, order := f.sortSpec()
+	if p.Sort != sort || p.Order != order {
+		return nil, fmt.Errorf("%w: cursor was created for a different sort order", ErrInvalidCursor)
+	}
+
+	c := &PhotoCursor{Sort: sort, Order: order, ID: id}
+	if sort == PhotoSortTitle {
+		var title string
+		if err := json.Unmarshal(p.Value, &title); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		c.Value = title
+		return c, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(p.Value))
+	dec.UseNumber()
+	var n json.Number
+	if err := dec.Decode(&n); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if sort == PhotoSortRelevance {
+		score, err := n.Float64()
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		c.Value = score
+		return c, nil
+	}
+	v, err := n.Int64()
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	c.Value = v
+	return c, nil
+}
+
+// filter 返回排在游标之后的条件
+func (c *PhotoCursor) filter() bson.M {
+	value := c.Value
+	if c.Sort == PhotoSortCreatedAt || c.Sort == PhotoSortTakenAt {
+		value = primitive.DateTime(value.(int64))
+	}
+	op := "$lt"
+	if c.Order == SortAsc {
+		op = "$gt"
+	}
+	field := c.field()
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{op: value}},
+		bson.M{field: value, "_id": bson.M{op: c.ID}},
+	}}
+}
+
+func (c *PhotoCursor) field() string {
+	if c.Sort == PhotoSortRelevance {
+		return relevanceField
+	}
+	return photoSortFields[c.Sort]
+}
+
+// sortSpec 返回实际使用的排序字段与方向：有关键词时默认按相关度，否则默认按上传时间倒序；
+// 按标题排序时默认升序。没有关键词时相关度排序退化为默认排序。
+func (f PhotoFilter) sortSpec() (string, string) {
+	sort := f.Sort
+	if sort == "" && f.Q != "" {
+		sort = PhotoSortRelevance
+	}
+	if sort == "" || (sort == PhotoSortRelevance && f.Q == "") {
+		sort = PhotoSortCreatedAt
+	}
+	order := f.Order
+	if order == "" {
+		order = SortDesc
+		if sort == PhotoSortTitle {
+			order = SortAsc
+		}
+	}
+	return sort, order
+}
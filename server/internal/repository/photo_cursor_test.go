@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"photoms/internal/models"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPhotoCursorRoundTrip(t *testing.T) {
+	photo := &models.Photo{
+		ID:        primitive.NewObjectID(),
+		CreatedAt: primitive.DateTime(1714000000123),
+		TakenAt:   primitive.DateTime(1700000000456),
+		Size:      5 << 20,
+		Title:     "海边 \"日落\"",
+		Rating:    4,
+		Score:     2.75,
+	}
+	tests := []struct {
+		name      string
+		filter    PhotoFilter
+		wantSort  string
+		wantOrder string
+		wantValue interface{}
+	}{
+		{"default", PhotoFilter{}, PhotoSortCreatedAt, SortDesc, int64(1714000000123)},
+		{"taken asc", PhotoFilter{Sort: PhotoSortTakenAt, Order: SortAsc}, PhotoSortTakenAt, SortAsc, int64(1700000000456)},
+		{"size", PhotoFilter{Sort: PhotoSortSize}, PhotoSortSize, SortDesc, int64(5 << 20)},
+		{"title", PhotoFilter{Sort: PhotoSortTitle}, PhotoSortTitle, SortAsc, "海边 \"日落\""},
+		{"rating", PhotoFilter{Sort: PhotoSortRating}, PhotoSortRating, SortDesc, int64(4)},
+		{"relevance by default with keywords", PhotoFilter{Q: "海边"}, PhotoSortRelevance, SortDesc, 2.75},
+		{"relevance without keywords", PhotoFilter{Sort: PhotoSortRelevance}, PhotoSortCreatedAt, SortDesc, int64(1714000000123)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := NewPhotoCursor(tt.filter, photo).Encode()
+			got, err := DecodePhotoCursor(encoded, tt.filter)
+			if err != nil {
+				t.Fatalf("DecodePhotoCursor: %v", err)
+			}
+			want := &PhotoCursor{Sort: tt.wantSort, Order: tt.wantOrder, Value: tt.wantValue, ID: photo.ID}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodePhotoCursorErrors(t *testing.T) {
+	photo := &models.Photo{ID: primitive.NewObjectID(), CreatedAt: primitive.DateTime(1)}
+	valid := NewPhotoCursor(PhotoFilter{}, photo).Encode()
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+		filter PhotoFilter
+	}{
+		{"not base64", "!!!", PhotoFilter{}},
+		{"not json", raw("nope"), PhotoFilter{}},
+		{"bad id", raw(`{"s":"createdAt","o":"desc","v":1,"id":"xyz"}`), PhotoFilter{}},
+		{"different sort", valid, PhotoFilter{Sort: PhotoSortSize}},
+		{"different order", valid, PhotoFilter{Order: SortAsc}},
+		{"fractional value", raw(`{"s":"createdAt","o":"desc","v":1.5,"id":"` + photo.ID.Hex() + `"}`), PhotoFilter{}},
+		{"numeric title", raw(`{"s":"title","o":"asc","v":1,"id":"` + photo.ID.Hex() + `"}`), PhotoFilter{Sort: PhotoSortTitle}},
+	}
+	for _, tt := range tests {
+		if _, err := DecodePhotoCursor(tt.cursor, tt.filter); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: err = %v, want ErrInvalidCursor", tt.name, err)
+		}
+	}
+}
+
+func TestPhotoCursorFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+	tests := []struct {
+		cursor PhotoCursor
+		want   bson.M
+	}{
+		{
+			PhotoCursor{Sort: PhotoSortCreatedAt, Order: SortDesc, Value: int64(42), ID: id},
+			bson.M{"$or": bson.A{
+				bson.M{"created_at": bson.M{"$lt": primitive.DateTime(42)}},
+				bson.M{"created_at": primitive.DateTime(42), "_id": bson.M{"$lt": id}},
+			}},
+		},
+		{
+			PhotoCursor{Sort: PhotoSortTitle, Order: SortAsc, Value: "a", ID: id},
+			bson.M{"$or": bson.A{
+				bson.M{"title": bson.M{"$gt": "a"}},
+				bson.M{"title": "a", "_id": bson.M{"$gt": id}},
+			}},
+		},
+		{
+			PhotoCursor{Sort: PhotoSortRelevance, Order: SortDesc, Value: 1.5, ID: id},
+			bson.M{"$or": bson.A{
+				bson.M{relevanceField: bson.M{"$lt": 1.5}},
+				bson.M{relevanceField: 1.5, "_id": bson.M{"$lt": id}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.cursor.filter(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s/%s filter = %v, want %v", tt.cursor.Sort, tt.cursor.Order, got, tt.want)
+		}
+	}
+}
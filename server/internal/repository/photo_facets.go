@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// FacetCount 某个取值及其图片数
+type FacetCount struct {
+	Value string `bson:"value" json:"value"`
+	Count int64  `bson:"count" json:"count"`
+}
+
+// CameraFacet 相机（品牌 + 型号）及其图片数
+type CameraFacet struct {
+	Make  string `bson:"make" json:"make"`
+	Model string `bson:"model" json:"model"`
+	Count int64  `bson:"count" json:"count"`
+}
+
+// DateFacet 按拍摄年份/月份统计的图片数（Month 为 0 表示整年）
+type DateFacet struct {
+	Year  int   `bson:"year" json:"year"`
+	Month int   `bson:"month,omitempty" json:"month,omitempty"`
+	Count int64 `bson:"count" json:"count"`
+}
+
+// PhotoFacets 满足过滤条件的图片的分面统计
+type PhotoFacets struct {
+	Total   int64                   `json:"total"`
+	Tags    map[string][]FacetCount `json:"tags"` // 按标签来源（USER/AI）分组
+	Cameras []CameraFacet           `json:"cameras"`
+	Lenses  []FacetCount            `json:"lenses"`
+	Years   []DateFacet             `json:"years"`  // 按拍摄时间（taken_at）统计，新的在前
+	Months  []DateFacet             `json:"months"` // 同上，按年月
+}
+
+// Facets 在与 Find 相同的过滤条件下统计标签、相机、镜头与拍摄年月的图片数。
+// 标签、相机与镜头各返回数量最多的 limit 项（标签按来源分别计算）。
+func (r *PhotoRepository) Facets(ctx context.Context, f PhotoFilter, limit int64) (*PhotoFacets, error) {
+	countBy := func(key interface{}, project bson.M) mongo.Pipeline {
+		project["_id"] = 0
+		project["count"] = 1
+		return mongo.Pipeline{
+			{{Key: "$group", Value: bson.M{"_id": key, "count": bson.M{"$sum": 1}}}},
+			{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+			{{Key: "$limit", Value: limit}},
+			{{Key: "$project", Value: project}},
+		}
+	}
+	byDate := func(key bson.M, sort bson.D) mongo.Pipeline {
+		return mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"taken_at": bson.M{"$type": "date"}}}},
+			{{Key: "$group", Value: bson.M{"_id": key, "count": bson.M{"$sum": 1}}}},
+			{{Key: "$sort", Value: sort}},
+			{{Key: "$project", Value: bson.M{"_id": 0, "year": "$_id.year", "month": "$_id.month", "count": 1}}},
+		}
+	}
+
+	tags := mongo.Pipeline{
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"source": "$tags.source", "name": "$tags.name"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id.name", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    "$_id.source",
+			"values": bson.M{"$push": bson.M{"value": "$_id.name", "count": "$count"}},
+		}}},
+		{{Key: "$project", Value: bson.M{"values": bson.M{"$slice": bson.A{"$values", limit}}}}},
+	}
+
+	cameras := append(mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"exif.make": bson.M{"$nin": bson.A{nil, ""}}}}},
+	}, countBy(bson.M{"make": "$exif.make", "model": "$exif.model"}, bson.M{"make": "$_id.make", "model": "$_id.model"})...)
+
+	lenses := append(mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"exif.lens": bson.M{"$nin": bson.A{nil, ""}}}}},
+	}, countBy("$exif.lens", bson.M{"value": "$_id"})...)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: buildPhotoFilter(f)}},
+		{{Key: "$facet", Value: bson.M{
+			"total":   bson.A{bson.M{"$count": "count"}},
+			"tags":    tags,
+			"cameras": cameras,
+			"lenses":  lenses,
+			"years": byDate(bson.M{"year": bson.M{"$year": "$taken_at"}},
+				bson.D{{Key: "_id.year", Value: -1}}),
+			"months": byDate(bson.M{"year": bson.M{"$year": "$taken_at"}, "month": bson.M{"$month": "$taken_at"}},
+				bson.D{{Key: "_id.year", Value: -1}, {Key: "_id.month", Value: -1}}),
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+		Tags []struct {
+			Source string       `bson:"_id"`
+			Values []FacetCount `bson:"values"`
+		} `bson:"tags"`
+		Cameras []CameraFacet `bson:"cameras"`
+		Lenses  []FacetCount  `bson:"lenses"`
+		Years   []DateFacet   `bson:"years"`
+		Months  []DateFacet   `bson:"months"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	facets := &PhotoFacets{
+		Tags:    map[string][]FacetCount{},
+		Cameras: []CameraFacet{},
+		Lenses:  []FacetCount{},
+		Years:   []DateFacet{},
+		Months:  []DateFacet{},
+	}
+	if len(results) == 0 {
+		return facets, nil
+	}
+	res := results[0]
+	if len(res.Total) > 0 {
+		facets.Total = res.Total[0].Count
+	}
+	for _, group := range res.Tags {
+		facets.Tags[group.Source] = group.Values
+	}
+	if res.Cameras != nil {
+		facets.Cameras = res.Cameras
+	}
+	if res.Lenses != nil {
+		facets.Lenses = res.Lenses
+	}
+	if res.Years != nil {
+		facets.Years = res.Years
+	}
+	if res.Months != nil {
+		facets.Months = res.Months
+	}
+	return facets, nil
+}
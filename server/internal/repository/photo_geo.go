@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"photoms/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// earthRadiusMeters $centerSphere 使用的地球半径（米）
+const earthRadiusMeters = 6378100
+
+// bboxChunkDegrees 矩形按经度拆成不超过该宽度的多边形，避免跨越半球时多边形被解释为其补集
+const bboxChunkDegrees = 90
+
+// maxMercatorLatitude Web Mercator 投影可表示的最大纬度
+const maxMercatorLatitude = 85.05112878
+
+// BBox 经纬度矩形，West > East 表示跨越 180° 经线
+type BBox struct {
+	West  float64 `bson:"west" json:"west"`
+	South float64 `bson:"south" json:"south"`
+	East  float64 `bson:"east" json:"east"`
+	North float64 `bson:"north" json:"north"`
+}
+
+// GeoCircle 以 (Lat, Lng) 为中心、Radius 米为半径的圆形范围
+type GeoCircle struct {
+	Lat    float64
+	Lng    float64
+	Radius float64
+}
+
+// filter 矩形的上下边是纬线而 GeoJSON 多边形的边是大圆弧，因此上下边每 1° 插入一个顶点。
+// 调用方需保证 West != East
+func (b BBox) filter() bson.M {
+	east := b.East
+	if east < b.West {
+		east += 360
+	}
+	// 顶点恰好落在极点时多边形会出现重复顶点
+	south := math.Max(b.South, -89.999999)
+	north := math.Min(b.North, 89.999999)
+
+	var polygons bson.A
+	for west := b.West; west < east; west += bboxChunkDegrees {
+		chunkEast := math.Min(west+bboxChunkDegrees, east)
+		var ring bson.A
+		for lng := west; lng < chunkEast; lng++ {
+			ring = append(ring, bson.A{normalizeLongitude(lng), south})
+		}
+		ring = append(ring, bson.A{normalizeLongitude(chunkEast), south})
+		for lng := chunkEast; lng > west; lng-- {
+			ring = append(ring, bson.A{normalizeLongitude(lng), north})
+		}
+		ring = append(ring,
+			bson.A{normalizeLongitude(west), north},
+			bson.A{normalizeLongitude(west), south},
+		)
+		polygons = append(polygons, bson.M{"location": bson.M{"$geoWithin": bson.M{
+			"$geometry": bson.M{"type": "Polygon", "coordinates": bson.A{ring}},
+		}}})
+	}
+	if len(polygons) == 1 {
+		return polygons[0].(bson.M)
+	}
+	return bson.M{"$or": polygons}
+}
+
+func normalizeLongitude(lng float64) float64 {
+	if lng > 180 {
+		return lng - 360
+	}
+	return lng
+}
+
+func (c GeoCircle) filter() bson.M {
+	return bson.M{"location": bson.M{"$geoWithin": bson.M{
+		"$centerSphere": bson.A{bson.A{c.Lng, c.Lat}, c.Radius / earthRadiusMeters},
+	}}}
+}
+
+// FindNear 查询 f.Near 范围内的图片，按距离由近到远排序，距离（米）写入 Photo.Distance。
+// 总数与分页结果在同一个 $geoNear 之后计算，范围判断一致
+func (r *PhotoRepository) FindNear(ctx context.Context, f PhotoFilter, page, limit int64) ([]*models.Photo, int64, error) {
+	near := *f.Near
+	// $geoNear 自身限定范围，query 中不能再包含地理条件
+	f.Near = nil
+	pipeline := mongo.Pipeline{
+		{{Key: "$geoNear", Value: bson.M{
+			"near":          bson.M{"type": "Point", "coordinates": bson.A{near.Lng, near.Lat}},
+			"key":           "location",
+			"distanceField": "_distance",
+			"maxDistance":   near.Radius,
+			"spherical":     true,
+			"query":         buildPhotoFilter(f),
+		}}},
+		{{Key: "$facet", Value: bson.M{
+			"total":  bson.A{bson.M{"$count": "count"}},
+			"photos": bson.A{bson.M{"$skip": (page - 1) * limit}, bson.M{"$limit": limit}},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+		Photos []struct {
+			models.Photo `bson:",inline"`
+			Distance     float64 `bson:"_distance"`
+		} `bson:"photos"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, 0, err
+	}
+	if len(results) == 0 {
+		return []*models.Photo{}, 0, nil
+	}
+
+	var total int64
+	if len(results[0].Total) > 0 {
+		total = results[0].Total[0].Count
+	}
+	photos := make([]*models.Photo, 0, len(results[0].Photos))
+	for i := range results[0].Photos {
+		photo := results[0].Photos[i].Photo
+		photo.Distance = results[0].Photos[i].Distance
+		photos = append(photos, &photo)
+	}
+	return photos, total, nil
+}
+
+// GeoCluster 地图上一个网格内的图片：中心为其中图片坐标的平均值，Bounds 为这些图片的范围
+type GeoCluster struct {
+	Lat     float64            `bson:"lat" json:"lat"`
+	Lng     float64            `bson:"lng" json:"lng"`
+	Count   int64              `bson:"count" json:"count"`
+	Bounds  BBox               `bson:"bounds" json:"bounds"`
+	PhotoID primitive.ObjectID `bson:"photo_id" json:"photoId"` // 网格内最新上传的图片，可作为封面
+	Photo   *models.Photo      `bson:"-" json:"photo,omitempty"`
+}
+
+// Clusters 将满足条件的有 location 的图片按 Web Mercator 网格聚合：zoom 级别下每 cellPixels 像素见方为一格。
+// 返回图片最多的 limit 个网格。
+func (r *PhotoRepository) Clusters(ctx context.Context, f PhotoFilter, zoom int, cellPixels float64, limit int64) ([]*GeoCluster, error) {
+	cells := math.Exp2(float64(zoom)) * 256 / cellPixels
+	lat := bson.M{"$max": bson.A{-maxMercatorLatitude, bson.M{"$min": bson.A{maxMercatorLatitude, "$lat"}}}}
+	sin := bson.M{"$sin": bson.M{"$degreesToRadians": lat}}
+	// y = 0.5 - ln((1+sinφ)/(1-sinφ)) / 4π
+	mercatorY := bson.M{"$subtract": bson.A{0.5, bson.M{"$divide": bson.A{
+		bson.M{"$ln": bson.M{"$divide": bson.A{bson.M{"$add": bson.A{1, sin}}, bson.M{"$subtract": bson.A{1, sin}}}}},
+		4 * math.Pi,
+	}}}}
+	// 180° 与 -180° 是同一条经线，归入同一列
+	lng := bson.M{"$cond": bson.A{bson.M{"$gte": bson.A{"$lng", 180}}, bson.M{"$subtract": bson.A{"$lng", 360}}, "$lng"}}
+	mercatorX := bson.M{"$divide": bson.A{bson.M{"$add": bson.A{lng, 180}}, 360}}
+	// 网格编号限制在 [0, 格数-1]，避免边界上的点（如最南端的纬度）多出一行/列
+	lastCell := math.Ceil(cells) - 1
+	cell := func(v bson.M) bson.M {
+		return bson.M{"$min": bson.A{bson.M{"$floor": bson.M{"$multiply": bson.A{v, cells}}}, lastCell}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$and": bson.A{buildPhotoFilter(f), bson.M{"location": bson.M{"$exists": true}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$project", Value: bson.M{
+			"lng": bson.M{"$arrayElemAt": bson.A{"$location.coordinates", 0}},
+			"lat": bson.M{"$arrayElemAt": bson.A{"$location.coordinates", 1}},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      bson.M{"x": cell(mercatorX), "y": cell(mercatorY)},
+			"count":    bson.M{"$sum": 1},
+			"lat":      bson.M{"$avg": "$lat"},
+			"lng":      bson.M{"$avg": "$lng"},
+			"south":    bson.M{"$min": "$lat"},
+			"north":    bson.M{"$max": "$lat"},
+			"west":     bson.M{"$min": "$lng"},
+			"east":     bson.M{"$max": "$lng"},
+			"photo_id": bson.M{"$first": "$_id"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$project", Value: bson.M{
+			"_id":      0,
+			"lat":      1,
+			"lng":      1,
+			"count":    1,
+			"photo_id": 1,
+			"bounds":   bson.M{"west": "$west", "south": "$south", "east": "$east", "north": "$north"},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	clusters := []*GeoCluster{}
+	if err = cursor.All(ctx, &clusters); err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
+
+// BackfillLocation 为有 exif.gps 但缺少 location 的历史图片生成 GeoJSON 点，返回更新的记录数
+func (r *PhotoRepository) BackfillLocation(ctx context.Context) (int64, error) {
+	filter := bson.M{
+		"location":           bson.M{"$exists": false},
+		"exif.gps.latitude":  bson.M{"$gte": -90, "$lte": 90},
+		"exif.gps.longitude": bson.M{"$gte": -180, "$lte": 180},
+	}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"location": bson.M{
+			"type":        "Point",
+			"coordinates": bson.A{"$exif.gps.longitude", "$exif.gps.latitude"},
+		}}}},
+	}
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
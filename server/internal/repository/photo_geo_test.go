@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// bboxRings 取出 BBox.filter 生成的各多边形外环
+func bboxRings(t *testing.T, filter bson.M) [][][2]float64 {
+	t.Helper()
+	conditions := bson.A{filter}
+	if or, ok := filter["$or"].(bson.A); ok {
+		conditions = or
+	}
+
+	var rings [][][2]float64
+	for _, c := range conditions {
+		geometry := c.(bson.M)["location"].(bson.M)["$geoWithin"].(bson.M)["$geometry"].(bson.M)
+		var ring [][2]float64
+		for _, v := range geometry["coordinates"].(bson.A)[0].(bson.A) {
+			p := v.(bson.A)
+			ring = append(ring, [2]float64{p[0].(float64), p[1].(float64)})
+		}
+		if first, last := ring[0], ring[len(ring)-1]; first != last {
+			t.Fatalf("ring is not closed: %v", ring)
+		}
+		rings = append(rings, ring)
+	}
+	return rings
+}
+
+// ringContains 平面射线法判断点是否在环内；环的经度先展开为连续值，以处理跨越 180° 经线的环
+func ringContains(ring [][2]float64, lng, lat float64) bool {
+	unwrapped := make([][2]float64, len(ring))
+	for i, p := range ring {
+		if i > 0 {
+			for p[0]-unwrapped[i-1][0] > 180 {
+				p[0] -= 360
+			}
+			for unwrapped[i-1][0]-p[0] > 180 {
+				p[0] += 360
+			}
+		}
+		unwrapped[i] = p
+	}
+
+	for _, x := range []float64{lng, lng + 360, lng - 360} {
+		inside := false
+		for i, j := 0, len(unwrapped)-1; i < len(unwrapped); j, i = i, i+1 {
+			a, b := unwrapped[i], unwrapped[j]
+			if (a[1] > lat) != (b[1] > lat) && x < (b[0]-a[0])*(lat-a[1])/(b[1]-a[1])+a[0] {
+				inside = !inside
+			}
+		}
+		if inside {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBBoxFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		box     BBox
+		inside  [][2]float64
+		outside [][2]float64
+	}{
+		{
+			name: "small box",
+			box:  BBox{West: 116.3, South: 39.9, East: 116.5, North: 40.0},
+			inside: [][2]float64{
+				{116.301, 39.901}, {116.499, 39.901}, {116.499, 39.999}, {116.301, 39.999}, {116.4, 39.95},
+			},
+			outside: [][2]float64{{116.6, 39.95}, {116.2, 39.95}, {116.4, 40.1}, {116.4, 39.8}},
+		},
+		{
+			name: "wide box",
+			box:  BBox{West: -10.5, South: 20.2, East: 150.7, North: 30.4},
+			inside: [][2]float64{
+				{-10.4, 20.3}, {150.6, 20.3}, {150.6, 30.3}, {-10.4, 30.3}, {70, 25}, {79.9, 20.3}, {80.1, 20.3},
+			},
+			outside: [][2]float64{{151, 25}, {-11, 25}, {70, 31}},
+		},
+		{
+			name: "crosses antimeridian",
+			box:  BBox{West: 170, South: -20, East: -170, North: -10},
+			inside: [][2]float64{
+				{170.1, -19.9}, {-170.1, -19.9}, {-170.1, -10.1}, {170.1, -10.1}, {179.99, -15}, {-179.99, -15},
+			},
+			outside: [][2]float64{{0, -15}, {-169, -15}, {169, -15}, {175, -21}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rings := bboxRings(t, tt.box.filter())
+			contains := func(p [2]float64) bool {
+				for _, ring := range rings {
+					if ringContains(ring, p[0], p[1]) {
+						return true
+					}
+				}
+				return false
+			}
+			for _, p := range tt.inside {
+				if !contains(p) {
+					t.Errorf("point %v should be inside %+v", p, tt.box)
+				}
+			}
+			for _, p := range tt.outside {
+				if contains(p) {
+					t.Errorf("point %v should be outside %+v", p, tt.box)
+				}
+			}
+		})
+	}
+}
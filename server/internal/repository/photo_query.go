@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"photoms/pkg/utils"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrInvalidQuery 检索语句无法解析
+var ErrInvalidQuery = errors.New("invalid query")
+
+// 检索语句支持的字段
+var queryFields = []string{"tag", "camera", "make", "model", "lens", "iso", "aperture", "focal", "shutter", "taken", "uploaded", "has"}
+
+// queryRangeFields 数值范围字段：解析函数与错误提示中的示例
+var queryRangeFields = map[string]struct {
+	parse   func(string) (float64, bool)
+	example string
+	target  func(*PhotoFilter) *NumberRange
+}{
+	"iso":      {parseQueryNumber, "1600, >1600 or 800..3200", func(f *PhotoFilter) *NumberRange { return &f.ISO }},
+	"aperture": {parseQueryAperture, "2.8, <=4 or f/1.4..f/2.8", func(f *PhotoFilter) *NumberRange { return &f.Aperture }},
+	"focal":    {parseQueryFocalLength, "35, >=85 or 24mm..70mm", func(f *PhotoFilter) *NumberRange { return &f.FocalLength }},
+	"shutter":  {utils.ParseShutterSpeed, "1/250, <1/60 or 1/1000..1/250", func(f *PhotoFilter) *NumberRange { return &f.ExposureTime }},
+}
+
+type queryToken struct {
+	pos     int // 在语句中的位置（从 1 开始，按字符计）
+	negated bool
+	field   string // 为空表示关键词
+	value   string
+}
+
+// ParsePhotoQuery 解析检索语句并合并到 f，例如：
+//
+//	tag:狗 camera:"Sony" iso:>1600 taken:2024-05..2024-08 -tag:截图 has:gps 海边
+//
+// 不带字段的词（可用双引号包含空格）作为关键词写入 f.Q，前缀 - 表示排除；
+// 冒号前不是支持的字段名时（如 Re:旅行、URL）整段按关键词处理。
+// 支持的字段：tag、camera（品牌或型号包含）、make/model/lens（精确匹配）、
+// iso/aperture/focal/shutter（N、>N、>=N、<N、<=N、A..B）、
+// taken/uploaded（YYYY、YYYY-MM 或 YYYY-MM-DD，同样支持比较与 A..B 区间）与 has:gps。
+// 语句中的条件覆盖 f 中已有的同类条件（tag/camera 为追加）；错误均包装 ErrInvalidQuery。
+func ParsePhotoQuery(query string, f *PhotoFilter) error {
+	tokens, err := scanQuery(query)
+	if err != nil {
+		return err
+	}
+
+	var words []string
+	dateField := ""
+	for _, t := range tokens {
+		if t.field == "" {
+			if t.negated {
+				f.ExcludeQ = append(f.ExcludeQ, t.value)
+			} else {
+				words = append(words, t.value)
+			}
+			continue
+		}
+
+		switch t.field {
+		case "tag":
+			if t.negated {
+				f.ExcludeTags = append(f.ExcludeTags, t.value)
+			} else {
+				f.Tags = append(f.Tags, t.value)
+			}
+			continue
+		case "camera":
+			if t.negated {
+				f.ExcludeCameras = append(f.ExcludeCameras, t.value)
+			} else {
+				f.Cameras = append(f.Cameras, t.value)
+			}
+			continue
+		case "has":
+			if !strings.EqualFold(t.value, "gps") {
+				return queryError(t.pos, "has: only supports gps, got %q", t.value)
+			}
+			hasGPS := !t.negated
+			f.HasGPS = &hasGPS
+			continue
+		}
+
+		if t.negated {
+			return queryError(t.pos, "%s: cannot be negated", t.field)
+		}
+		switch t.field {
+		case "make":
+			f.Make = t.value
+		case "model":
+			f.Model = t.value
+		case "lens":
+			f.Lens = t.value
+		case "taken", "uploaded":
+			if dateField != "" && dateField != t.field {
+				return queryError(t.pos, "taken: and uploaded: cannot be combined")
+			}
+			dateField = t.field
+			start, end, err := parseQueryDateRange(t.value)
+			if err != nil {
+				return queryError(t.pos, "%s: %v", t.field, err)
+			}
+			f.StartDate, f.EndDate = start, end
+			f.DateField = DateFieldUploaded
+			if t.field == "taken" {
+				f.DateField = DateFieldTaken
+			}
+		default:
+			field := queryRangeFields[t.field]
+			r, err := parseQueryRange(t.value, field.parse)
+			if err != nil {
+				return queryError(t.pos, "%s: %v (expected e.g. %s)", t.field, err, field.example)
+			}
+			*field.target(f) = r
+		}
+	}
+
+	f.Q = strings.Join(words, " ")
+	return nil
+}
+
+func queryError(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s (at position %d)", ErrInvalidQuery, fmt.Sprintf(format, args...), pos)
+}
+
+// scanQuery 按空白切分语句：[-]word、[-]"phrase"、[-]field:value、[-]field:"value"
+func scanQuery(query string) ([]queryToken, error) {
+	runes := []rune(query)
+	var tokens []queryToken
+	i := 0
+	for i < len(runes) {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+		t := queryToken{pos: i + 1}
+		if runes[i] == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			t.negated = true
+			i++
+		}
+
+		if runes[i] == '"' {
+			value, next, err := scanQuoted(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			t.value, i = value, next
+		} else {
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != ':' && runes[i] != '"' {
+				i++
+			}
+			word := string(runes[start:i])
+			if i < len(runes) && runes[i] == ':' && isQueryField(strings.ToLower(word)) {
+				t.field = strings.ToLower(word)
+				i++
+				if i < len(runes) && runes[i] == '"' {
+					value, next, err := scanQuoted(runes, i)
+					if err != nil {
+						return nil, err
+					}
+					t.value, i = value, next
+				} else {
+					start = i
+					for i < len(runes) && !unicode.IsSpace(runes[i]) {
+						i++
+					}
+					t.value = string(runes[start:i])
+				}
+			} else {
+				// 不是支持的字段（如 12:30、Re:旅行、http://...）时整段作为关键词
+				for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '"' {
+					i++
+				}
+				t.value = string(runes[start:i])
+			}
+		}
+
+		t.value = strings.TrimSpace(t.value)
+		if t.value == "" {
+			if t.field != "" {
+				return nil, queryError(t.pos, "missing value for %s:", t.field)
+			}
+			continue
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, nil
+}
+
+// scanQuoted 读取从 runes[start]（双引号）开始的带引号文本，返回内容与其后的位置
+func scanQuoted(runes []rune, start int) (string, int, error) {
+	for i := start + 1; i < len(runes); i++ {
+		if runes[i] == '"' {
+			return string(runes[start+1 : i]), i + 1, nil
+		}
+	}
+	return "", 0, queryError(start+1, "unterminated quote")
+}
+
+func isQueryField(name string) bool {
+	for _, field := range queryFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// splitQueryRange 将 A..B、>=A、>A、<=B、<B、A 拆成上下界（空字符串表示不限）与是否不含端点
+func splitQueryRange(value string) (lo, hi string, loExclusive, hiExclusive bool, err error) {
+	if before, after, ok := strings.Cut(value, ".."); ok {
+		lo, hi = strings.TrimSpace(before), strings.TrimSpace(after)
+		if lo == "" && hi == "" {
+			return "", "", false, false, fmt.Errorf("empty range %q", value)
+		}
+		return lo, hi, false, false, nil
+	}
+	switch {
+	case strings.HasPrefix(value, ">="):
+		return strings.TrimSpace(value[2:]), "", false, false, nil
+	case strings.HasPrefix(value, "<="):
+		return "", strings.TrimSpace(value[2:]), false, false, nil
+	case strings.HasPrefix(value, ">"):
+		return strings.TrimSpace(value[1:]), "", true, false, nil
+	case strings.HasPrefix(value, "<"):
+		return "", strings.TrimSpace(value[1:]), false, true, nil
+	}
+	value = strings.TrimPrefix(value, "=")
+	return value, value, false, false, nil
+}
+
+func parseQueryRange(value string, parse func(string) (float64, bool)) (NumberRange, error) {
+	lo, hi, loExclusive, hiExclusive, err := splitQueryRange(value)
+	if err != nil {
+		return NumberRange{}, err
+	}
+	r := NumberRange{MinExclusive: loExclusive, MaxExclusive: hiExclusive}
+	for _, bound := range []struct {
+		value string
+		dst   **float64
+	}{{lo, &r.Min}, {hi, &r.Max}} {
+		if bound.value == "" {
+			continue
+		}
+		n, ok := parse(bound.value)
+		if !ok {
+			return r, fmt.Errorf("invalid value %q", bound.value)
+		}
+		*bound.dst = &n
+	}
+	if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+		return r, fmt.Errorf("range %q is empty", value)
+	}
+	return r, nil
+}
+
+// parseQueryDateRange 日期按服务器时区解析；YYYY/YYYY-MM 表示整年/整月
+func parseQueryDateRange(value string) (*time.Time, *time.Time, error) {
+	lo, hi, loExclusive, hiExclusive, err := splitQueryRange(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	var start, end *time.Time
+	if lo != "" {
+		from, next, ok := parseQueryPeriod(lo)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid date %q (expected YYYY, YYYY-MM or YYYY-MM-DD)", lo)
+		}
+		if loExclusive {
+			from = next
+		}
+		start = &from
+	}
+	if hi != "" {
+		from, next, ok := parseQueryPeriod(hi)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid date %q (expected YYYY, YYYY-MM or YYYY-MM-DD)", hi)
+		}
+		to := next.Add(-time.Nanosecond)
+		if hiExclusive {
+			to = from.Add(-time.Nanosecond)
+		}
+		end = &to
+	}
+	if start != nil && end != nil && start.After(*end) {
+		return nil, nil, fmt.Errorf("range %q is empty", value)
+	}
+	return start, end, nil
+}
+
+// parseQueryPeriod 返回日期所表示时间段的起点与下一个时间段的起点
+func parseQueryPeriod(value string) (time.Time, time.Time, bool) {
+	for _, layout := range []struct {
+		layout              string
+		years, months, days int
+	}{
+		{"2006-01-02", 0, 0, 1},
+		{"2006-01", 0, 1, 0},
+		{"2006", 1, 0, 0},
+	} {
+		if t, err := time.ParseInLocation(layout.layout, value, time.Local); err == nil {
+			return t, t.AddDate(layout.years, layout.months, layout.days), true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+func parseQueryNumber(value string) (float64, bool) {
+	n, ok := utils.ParseFinite(value)
+	return n, ok && n >= 0
+}
+
+// parseQueryAperture 光圈可写作 2.8、f2.8 或 f/2.8
+func parseQueryAperture(value string) (float64, bool) {
+	lower := strings.ToLower(value)
+	lower = strings.TrimPrefix(strings.TrimPrefix(lower, "f"), "/")
+	return parseQueryNumber(lower)
+}
+
+// parseQueryFocalLength 焦距可带 mm 后缀
+func parseQueryFocalLength(value string) (float64, bool) {
+	return parseQueryNumber(strings.TrimSuffix(strings.ToLower(value), "mm"))
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+
+func boolPtr(v bool) *bool { return &v }
+
+func TestScanQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []queryToken
+	}{
+		{"", nil},
+		{"  海边  日落 ", []queryToken{{pos: 3, value: "海边"}, {pos: 7, value: "日落"}}},
+		{`"sea side" -"night"`, []queryToken{{pos: 1, value: "sea side"}, {pos: 12, negated: true, value: "night"}}},
+		{`TAG:狗 camera:"Sony A7"`, []queryToken{{pos: 1, field: "tag", value: "狗"}, {pos: 7, field: "camera", value: "Sony A7"}}},
+		{"-tag:截图", []queryToken{{pos: 1, negated: true, field: "tag", value: "截图"}}},
+		// 不是支持的字段时整段作为关键词
+		{"Re:旅行 12:30 http://a.b/c", []queryToken{{pos: 1, value: "Re:旅行"}, {pos: 7, value: "12:30"}, {pos: 13, value: "http://a.b/c"}}},
+		// 单独的 - 是关键词
+		{"a - b", []queryToken{{pos: 1, value: "a"}, {pos: 3, value: "-"}, {pos: 5, value: "b"}}},
+		{`"" x`, []queryToken{{pos: 4, value: "x"}}},
+	}
+	for _, tt := range tests {
+		got, err := scanQuery(tt.query)
+		if err != nil {
+			t.Errorf("scanQuery(%q) error: %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("scanQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParsePhotoQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		base  PhotoFilter
+		want  PhotoFilter
+	}{
+		{
+			name:  "keywords",
+			query: `海边 "sea side" -夜景`,
+			want:  PhotoFilter{Q: "海边 sea side", ExcludeQ: []string{"夜景"}},
+		},
+		{
+			name:  "tags and cameras append",
+			query: "tag:狗 -tag:截图 camera:sony -camera:canon",
+			base:  PhotoFilter{Tags: []string{"猫"}},
+			want: PhotoFilter{
+				Tags: []string{"猫", "狗"}, ExcludeTags: []string{"截图"},
+				Cameras: []string{"sony"}, ExcludeCameras: []string{"canon"},
+			},
+		},
+		{
+			name:  "exact fields override",
+			query: `make:SONY model:"ILCE-7M4" lens:"FE 35mm"`,
+			base:  PhotoFilter{Make: "Canon"},
+			want:  PhotoFilter{Make: "SONY", Model: "ILCE-7M4", Lens: "FE 35mm"},
+		},
+		{
+			name:  "has gps",
+			query: "has:GPS",
+			want:  PhotoFilter{HasGPS: boolPtr(true)},
+		},
+		{
+			name:  "has no gps",
+			query: "-has:gps",
+			want:  PhotoFilter{HasGPS: boolPtr(false)},
+		},
+		{
+			name:  "number ranges",
+			query: "iso:>1600 aperture:f/1.4..f/2.8 focal:<=85mm shutter:1/250",
+			want: PhotoFilter{
+				ISO:          NumberRange{Min: floatPtr(1600), MinExclusive: true},
+				Aperture:     NumberRange{Min: floatPtr(1.4), Max: floatPtr(2.8)},
+				FocalLength:  NumberRange{Max: floatPtr(85)},
+				ExposureTime: NumberRange{Min: floatPtr(1.0 / 250), Max: floatPtr(1.0 / 250)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.base
+			if err := ParsePhotoQuery(tt.query, &got); err != nil {
+				t.Fatalf("ParsePhotoQuery(%q) error: %v", tt.query, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePhotoQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePhotoQueryDates(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.Local) }
+	tests := []struct {
+		query     string
+		dateField string
+		start     time.Time
+		end       time.Time
+	}{
+		{"taken:2024", DateFieldTaken, date(2024, 1, 1), date(2025, 1, 1)},
+		{"taken:2024-05..2024-08", DateFieldTaken, date(2024, 5, 1), date(2024, 9, 1)},
+		{"uploaded:2024-02-29", DateFieldUploaded, date(2024, 2, 29), date(2024, 3, 1)},
+		{"taken:>2024-05", DateFieldTaken, date(2024, 6, 1), time.Time{}},
+		{"taken:<2024", DateFieldTaken, time.Time{}, date(2024, 1, 1)},
+	}
+	for _, tt := range tests {
+		var f PhotoFilter
+		if err := ParsePhotoQuery(tt.query, &f); err != nil {
+			t.Errorf("ParsePhotoQuery(%q) error: %v", tt.query, err)
+			continue
+		}
+		if f.DateField != tt.dateField {
+			t.Errorf("%q: dateField = %q, want %q", tt.query, f.DateField, tt.dateField)
+		}
+		// end 为区间内最后一刻，即下一个时间段起点前 1ns
+		if got := f.StartDate; (got == nil) != tt.start.IsZero() || (got != nil && !got.Equal(tt.start)) {
+			t.Errorf("%q: start = %v, want %v", tt.query, got, tt.start)
+		}
+		if got := f.EndDate; (got == nil) != tt.end.IsZero() || (got != nil && !got.Equal(tt.end.Add(-time.Nanosecond))) {
+			t.Errorf("%q: end = %v, want just before %v", tt.query, got, tt.end)
+		}
+	}
+}
+
+func TestParsePhotoQueryErrors(t *testing.T) {
+	for _, query := range []string{
+		`"unterminated`,
+		`tag:"unterminated`,
+		"tag:",
+		"has:location",
+		"-make:sony",
+		"iso:abc",
+		"iso:-100",
+		"iso:NaN",
+		"iso:3200..100",
+		"iso:..",
+		"taken:2024-13",
+		"taken:2024..2023",
+		"taken:2024 uploaded:2024",
+	} {
+		err := ParsePhotoQuery(query, &PhotoFilter{})
+		if !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("ParsePhotoQuery(%q) = %v, want ErrInvalidQuery", query, err)
+		}
+	}
+}
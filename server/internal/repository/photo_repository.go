@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"photoms/internal/models"
+	"photoms/pkg/textsearch"
 	"regexp"
 	"time"
 
@@ -22,10 +23,64 @@ func NewPhotoRepository(db *mongo.Database) *PhotoRepository {
 	}
 }
 
+// exifIndexFields 按 EXIF 过滤时使用的字段（相机型号与品牌组合为一个索引；品牌/型号/镜头使用小写的匹配字段）
+var exifIndexFields = [][]string{
+	{"exif.make_key", "exif.model_key"},
+	{"exif.lens_key"},
+	{"exif.iso"},
+	{"exif.aperture"},
+	{"exif.focal_length"},
+	{"exif.exposure_time"},
+}
+
+// EnsureIndexes 创建图片列表各排序方式、EXIF 过滤、关键词检索与地理检索所需的索引
+// （均以 user_id 开头，排序索引末尾的 _id 用于游标分页）
+func (r *PhotoRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := make([]mongo.IndexModel, 0, len(photoSortFields)+len(exifIndexFields)+2)
+	for _, field := range photoSortFields {
+		indexes = append(indexes, mongo.IndexModel{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: field, Value: -1}, {Key: "_id", Value: -1}},
+		})
+	}
+	for _, fields := range exifIndexFields {
+		keys := bson.D{{Key: "user_id", Value: 1}}
+		for _, field := range fields {
+			keys = append(keys, bson.E{Key: field, Value: 1})
+		}
+		indexes = append(indexes, mongo.IndexModel{Keys: keys})
+	}
+	indexes = append(indexes,
+		mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "search.terms", Value: 1}}},
+		mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "location", Value: "2dsphere"}}},
+	)
+	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// BackfillRating 为历史图片补齐 rating 字段（缺失时为 0，游标分页要求排序字段存在），返回更新的记录数
+func (r *PhotoRepository) BackfillRating(ctx context.Context) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"rating": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"rating": 0}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
 func (r *PhotoRepository) Create(ctx context.Context, photo *models.Photo) error {
 	now := primitive.NewDateTimeFromTime(time.Now())
 	photo.CreatedAt = now
 	photo.UpdatedAt = photo.CreatedAt
+	if photo.TakenAt == 0 {
+		photo.TakenAt = now
+	}
+	photo.Search = textsearch.BuildIndex(photo.Title, photo.Description, photo.Tags)
+	if photo.Exif != nil {
+		photo.Location = models.NewGeoPoint(photo.Exif.GPS)
+		photo.Exif.SetMatchKeys()
+	}
 
 	result, err := r.collection.InsertOne(ctx, photo)
 	if err != nil {
@@ -45,55 +100,240 @@ func (r *PhotoRepository) FindByHash(ctx context.Context, hash string) (*models.
 	return &photo, nil
 }
 
-func (r *PhotoRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID, page, limit int64, q, tag string, startDate, endDate *time.Time) ([]*models.Photo, int64, error) {
-	return r.Find(ctx, &userID, page, limit, q, tag, startDate, endDate)
+// PhotoFilter 图片查询条件，零值字段不参与过滤。列表、相册、MCP 检索共用同一套过滤逻辑。
+type PhotoFilter struct {
+	UserID *primitive.ObjectID
+	// Q 关键词，按检索词（见 pkg/textsearch）匹配标题、描述与标签，须包含全部检索词
+	Q         string
+	Tag       string
+	StartDate *time.Time
+	EndDate   *time.Time
+	// Tags 按 TagMode 匹配的标签（与 Tag 一样不区分大小写），ExcludeTags 不能包含的标签（不论来源）
+	Tags        []string
+	TagMode     string
+	ExcludeTags []string
+	// TagSource 只匹配该来源（models.TagSource*）的标签；MinTagScore 只匹配置信度不低于该值的 AI 标签。
+	// 作用于 Tag/Tags 的匹配，没有指定标签时要求图片至少有一个满足条件的标签
+	TagSource   string
+	MinTagScore *float64
+	// ExcludeQ 不能匹配的关键词（每项按 Q 的规则匹配）
+	ExcludeQ []string
+	// Cameras 相机品牌或型号包含该文本（不区分大小写，多项需同时满足），ExcludeCameras 不能包含
+	Cameras        []string
+	ExcludeCameras []string
+	// Make/Model/Lens 精确匹配（不区分大小写）
+	Make   string
+	Model  string
+	Lens   string
+	HasGPS *bool
+	// BBox/Near 限定在该经纬度矩形/圆形范围内（只匹配有 location 的图片）
+	BBox *BBox
+	Near *GeoCircle
+	// EXIF 数值范围：ISO、光圈 f 值、焦距（mm）、曝光时间（秒）
+	ISO          NumberRange
+	Aperture     NumberRange
+	FocalLength  NumberRange
+	ExposureTime NumberRange
+	MinRating    *int
+	Favorite     *bool
+	// ColorLabel 颜色标签，ColorLabelNone 表示没有颜色标签
+	ColorLabel string
+	// DateField 为 taken 时 StartDate/EndDate 按拍摄时间（taken_at）过滤，否则按上传时间
+	DateField string
+	// Sort 排序字段（PhotoSort* 常量），Order 为 asc/desc，均为空时有关键词按相关度、否则最新上传的在前；FindOrdered 忽略排序
+	Sort  string
+	Order string
+	// After 非空时从该游标之后开始（keyset 分页），忽略 page；FindOrdered 忽略该字段
+	After *PhotoCursor
+	// IDs 非 nil 时限定在这些图片内（如相册）
+	IDs []primitive.ObjectID
+	// Within 额外的限定条件（如智能相册保存的查询），须同时满足
+	Within *PhotoFilter
+	// Trashed 为 true 时只查询回收站中的图片，否则排除回收站中的图片
+	Trashed bool
 }
 
-// Find searches photos with optional user scope.
-// If userID is nil, it returns photos for all users.
-func (r *PhotoRepository) Find(ctx context.Context, userID *primitive.ObjectID, page, limit int64, q, tag string, startDate, endDate *time.Time) ([]*models.Photo, int64, error) {
-	skip := (page - 1) * limit
+// NumberRange 数值范围过滤（默认闭区间，*Exclusive 为 true 时不含该端点），Min/Max 为空表示不限
+type NumberRange struct {
+	Min          *float64
+	Max          *float64
+	MinExclusive bool
+	MaxExclusive bool
+}
 
-	filter := bson.M{}
-	if userID != nil {
-		filter["user_id"] = *userID
+func (r NumberRange) filter() bson.M {
+	if r.Min == nil && r.Max == nil {
+		return nil
 	}
-	if tag != "" {
-		filter["tags.name"] = primitive.Regex{
-			Pattern: "^" + regexp.QuoteMeta(tag) + "$",
-			Options: "i",
+	cond := bson.M{}
+	if r.Min != nil {
+		op := "$gte"
+		if r.MinExclusive {
+			op = "$gt"
 		}
+		cond[op] = *r.Min
 	}
-
-	if q != "" {
-		regex := primitive.Regex{
-			Pattern: regexp.QuoteMeta(q),
-			Options: "i",
-		}
-		filter["$or"] = bson.A{
-			bson.M{"title": regex},
-			bson.M{"description": regex},
-			bson.M{"tags.name": regex},
+	if r.Max != nil {
+		op := "$lte"
+		if r.MaxExclusive {
+			op = "$lt"
 		}
+		cond[op] = *r.Max
 	}
+	return cond
+}
 
-	if startDate != nil || endDate != nil {
-		createdAt := bson.M{}
-		if startDate != nil {
-			createdAt["$gte"] = primitive.NewDateTimeFromTime(*startDate)
-		}
-		if endDate != nil {
-			createdAt["$lte"] = primitive.NewDateTimeFromTime(*endDate)
-		}
-		filter["created_at"] = createdAt
+// MaxFilterTags Tags/ExcludeTags 中最多的标签数（由调用方校验）
+const MaxFilterTags = 20
+
+// 多个标签的匹配方式：全部包含（默认）或包含任一
+const (
+	TagModeAll = "all"
+	TagModeAny = "any"
+)
+
+// ColorLabelNone 过滤没有颜色标签的图片
+const ColorLabelNone = "none"
+
+// 日期过滤的字段：上传时间（默认）或拍摄时间
+const (
+	DateFieldUploaded = "uploaded"
+	DateFieldTaken    = "taken"
+)
+
+func (r *PhotoRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID, page, limit int64, q, tag string, startDate, endDate *time.Time) ([]*models.Photo, int64, error) {
+	return r.Find(ctx, PhotoFilter{UserID: &userID, Q: q, Tag: tag, StartDate: startDate, EndDate: endDate}, page, limit)
+}
+
+// Find searches photos matching the filter, newest first (trash: most recently deleted first)
+// unless f.Sort says otherwise; keyword searches are ranked by relevance by default.
+// If filter.UserID is nil, it returns photos for all users. The returned total ignores f.After.
+func (r *PhotoRepository) Find(ctx context.Context, f PhotoFilter, page, limit int64) ([]*models.Photo, int64, error) {
+	if sort, _ := f.sortSpec(); sort == PhotoSortRelevance && !f.Trashed {
+		return r.findByRelevance(ctx, f, page, limit)
 	}
 
+	skip := (page - 1) * limit
+	filter := buildPhotoFilter(f)
+
+	query := filter
+	if f.After != nil && !f.Trashed {
+		skip = 0
+		query = bson.M{"$and": bson.A{filter, f.After.filter()}}
+	}
 	opts := options.Find().
 		SetSkip(skip).
 		SetLimit(limit).
-		SetSort(bson.D{{Key: "created_at", Value: -1}})
+		SetSort(photoSort(f))
 
-	cursor, err := r.collection.Find(ctx, filter, opts)
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var photos []*models.Photo
+	if err = cursor.All(ctx, &photos); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return photos, total, nil
+}
+
+// findByRelevance 按相关度排序的检索：各字段命中的检索词数按权重（标题 > 标签 > 描述）累加，
+// 再除以查询的检索词数。相关度写入 Photo.Score。
+func (r *PhotoRepository) findByRelevance(ctx context.Context, f PhotoFilter, page, limit int64) ([]*models.Photo, int64, error) {
+	filter := buildPhotoFilter(f)
+	_, order := f.sortSpec()
+	dir := -1
+	if order == SortAsc {
+		dir = 1
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$addFields", Value: bson.M{relevanceField: relevanceScore(textsearch.QueryTerms(f.Q))}}},
+	}
+	skip := (page - 1) * limit
+	if f.After != nil {
+		skip = 0
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: f.After.filter()}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: relevanceField, Value: dir}, {Key: "_id", Value: dir}}}},
+		bson.D{{Key: "$skip", Value: skip}},
+		bson.D{{Key: "$limit", Value: limit}},
+	)
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		models.Photo `bson:",inline"`
+		Score        float64 `bson:"_score"`
+	}
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, 0, err
+	}
+	photos := make([]*models.Photo, 0, len(docs))
+	for i := range docs {
+		photo := docs[i].Photo
+		photo.Score = docs[i].Score
+		photos = append(photos, &photo)
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return photos, total, nil
+}
+
+// relevanceScore 相关度表达式，terms 为空时相关度均为 0
+func relevanceScore(terms []string) interface{} {
+	if len(terms) == 0 {
+		return 0
+	}
+	matched := func(field string) bson.M {
+		return bson.M{"$size": bson.M{"$setIntersection": bson.A{bson.M{"$ifNull": bson.A{field, bson.A{}}}, terms}}}
+	}
+	return bson.M{"$divide": bson.A{
+		bson.M{"$add": bson.A{
+			bson.M{"$multiply": bson.A{textsearch.TitleWeight, matched("$search.title")}},
+			bson.M{"$multiply": bson.A{textsearch.TagWeight, matched("$search.tags")}},
+			bson.M{"$multiply": bson.A{textsearch.DescriptionWeight, matched("$search.description")}},
+		}},
+		len(terms),
+	}}
+}
+
+// FindOrdered 在 f.IDs 范围内查询，并按 f.IDs 的顺序返回（用于相册手动排序）
+func (r *PhotoRepository) FindOrdered(ctx context.Context, f PhotoFilter, page, limit int64) ([]*models.Photo, int64, error) {
+	filter := buildPhotoFilter(f)
+	ids := f.IDs
+	if ids == nil {
+		ids = []primitive.ObjectID{}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$addFields", Value: bson.M{"_order": bson.M{"$indexOfArray": bson.A{ids, "$_id"}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_order", Value: 1}}}},
+		{{Key: "$skip", Value: (page - 1) * limit}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$project", Value: bson.M{"_order": 0}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -112,6 +352,194 @@ func (r *PhotoRepository) Find(ctx context.Context, userID *primitive.ObjectID,
 	return photos, total, nil
 }
 
+// wallClockBound taken_at 以拍摄地墙上时间（UTC 表示）存储，查询边界同样只取其墙上时间：
+// 2024-05-01（无论按哪个时区解析）匹配当地时间 5 月 1 日拍摄的图片
+func wallClockBound(t time.Time) primitive.DateTime {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return primitive.NewDateTimeFromTime(wall)
+}
+
+// photoSort 返回排序条件；排序值相同时按 _id 决定先后，保证分页稳定
+func photoSort(f PhotoFilter) bson.D {
+	if f.Trashed {
+		return bson.D{{Key: "deleted_at", Value: -1}}
+	}
+	sort, order := f.sortSpec()
+	dir := -1
+	if order == SortAsc {
+		dir = 1
+	}
+	return bson.D{{Key: photoSortFields[sort], Value: dir}, {Key: "_id", Value: dir}}
+}
+
+func buildPhotoFilter(f PhotoFilter) bson.M {
+	filter := bson.M{}
+	if f.UserID != nil {
+		filter["user_id"] = *f.UserID
+	}
+	if f.IDs != nil {
+		filter["_id"] = bson.M{"$in": f.IDs}
+	}
+	filter["deleted_at"] = bson.M{"$exists": f.Trashed}
+	// and/nor 收集作用于同一字段的多个条件
+	and := tagConditions(f)
+	var nor bson.A
+	for _, tag := range f.ExcludeTags {
+		nor = append(nor, bson.M{"tags.name": exactMatch(tag)})
+	}
+	camera := func(value string) bson.M {
+		regex := primitive.Regex{Pattern: regexp.QuoteMeta(value), Options: "i"}
+		return bson.M{"$or": bson.A{bson.M{"exif.make": regex}, bson.M{"exif.model": regex}}}
+	}
+	for _, value := range f.Cameras {
+		and = append(and, camera(value))
+	}
+	for _, value := range f.ExcludeCameras {
+		nor = append(nor, camera(value))
+	}
+	if f.BBox != nil {
+		and = append(and, f.BBox.filter())
+	}
+	if f.Near != nil {
+		and = append(and, f.Near.filter())
+	}
+
+	if f.Make != "" {
+		filter["exif.make_key"] = models.ExifMatchKey(f.Make)
+	}
+	if f.Model != "" {
+		filter["exif.model_key"] = models.ExifMatchKey(f.Model)
+	}
+	if f.Lens != "" {
+		filter["exif.lens_key"] = models.ExifMatchKey(f.Lens)
+	}
+	if f.HasGPS != nil {
+		filter["exif.gps"] = bson.M{"$exists": *f.HasGPS}
+	}
+	for field, r := range map[string]NumberRange{
+		"exif.iso":           f.ISO,
+		"exif.aperture":      f.Aperture,
+		"exif.focal_length":  f.FocalLength,
+		"exif.exposure_time": f.ExposureTime,
+	} {
+		if cond := r.filter(); cond != nil {
+			filter[field] = cond
+		}
+	}
+	// 未评分的图片 rating 为 0，minRating 为 0 时不过滤
+	if f.MinRating != nil && *f.MinRating > 0 {
+		filter["rating"] = bson.M{"$gte": *f.MinRating}
+	}
+	if f.Favorite != nil {
+		if *f.Favorite {
+			filter["favorite"] = true
+		} else {
+			filter["favorite"] = bson.M{"$ne": true}
+		}
+	}
+	if f.ColorLabel == ColorLabelNone {
+		filter["color_label"] = bson.M{"$in": bson.A{nil, ""}}
+	} else if f.ColorLabel != "" {
+		filter["color_label"] = f.ColorLabel
+	}
+
+	// 关键词中没有可检索的内容（如只有标点）时不过滤
+	if terms := textsearch.QueryTerms(f.Q); len(terms) > 0 {
+		filter["search.terms"] = bson.M{"$all": terms}
+	}
+	for _, q := range f.ExcludeQ {
+		if terms := textsearch.QueryTerms(q); len(terms) > 0 {
+			nor = append(nor, bson.M{"search.terms": bson.M{"$all": terms}})
+		}
+	}
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+	if len(nor) > 0 {
+		filter["$nor"] = nor
+	}
+
+	if f.StartDate != nil || f.EndDate != nil {
+		field, bound := "created_at", primitive.NewDateTimeFromTime
+		if f.DateField == DateFieldTaken {
+			field, bound = "taken_at", wallClockBound
+		}
+		dateRange := bson.M{}
+		if f.StartDate != nil {
+			dateRange["$gte"] = bound(*f.StartDate)
+		}
+		if f.EndDate != nil {
+			dateRange["$lte"] = bound(*f.EndDate)
+		}
+		filter[field] = dateRange
+	}
+
+	if f.Within != nil {
+		return bson.M{"$and": bson.A{filter, buildPhotoFilter(*f.Within)}}
+	}
+	return filter
+}
+
+// tagConditions 返回标签匹配条件：Tag 与 TagModeAll 下的 Tags 每个都须存在，TagModeAny 下 Tags 至少存在一个；
+// 指定 TagSource/MinTagScore 时，须由满足条件的同一个标签匹配
+func tagConditions(f PhotoFilter) bson.A {
+	elem := func(name interface{}) bson.M {
+		cond := bson.M{}
+		if name != nil {
+			cond["name"] = name
+		}
+		if f.TagSource != "" {
+			cond["source"] = f.TagSource
+		}
+		if f.MinTagScore != nil && f.TagSource != models.TagSourceUser {
+			minScore := bson.M{"$gte": *f.MinTagScore}
+			if f.TagSource == models.TagSourceAI {
+				cond["score"] = minScore
+			} else {
+				// 用户标签没有置信度，不受影响
+				cond["$or"] = bson.A{bson.M{"source": bson.M{"$ne": models.TagSourceAI}}, bson.M{"score": minScore}}
+			}
+		}
+		return bson.M{"tags": bson.M{"$elemMatch": cond}}
+	}
+	filtered := f.TagSource != "" || f.MinTagScore != nil
+
+	var conds bson.A
+	required := f.Tags
+	if f.TagMode == TagModeAny {
+		required = nil
+		if len(f.Tags) > 0 {
+			names := make(bson.A, 0, len(f.Tags))
+			for _, tag := range f.Tags {
+				names = append(names, exactMatch(tag))
+			}
+			conds = append(conds, elem(bson.M{"$in": names}))
+		}
+	}
+	if f.Tag != "" {
+		required = append([]string{f.Tag}, required...)
+	}
+	for _, tag := range required {
+		if filtered {
+			conds = append(conds, elem(exactMatch(tag)))
+		} else {
+			conds = append(conds, bson.M{"tags.name": exactMatch(tag)})
+		}
+	}
+	if len(conds) == 0 && filtered {
+		conds = append(conds, elem(nil))
+	}
+	return conds
+}
+
+// exactMatch 不区分大小写的整值匹配
+func exactMatch(value string) primitive.Regex {
+	return primitive.Regex{
+		Pattern: "^" + regexp.QuoteMeta(value) + "$",
+		Options: "i",
+	}
+}
+
 func (r *PhotoRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Photo, error) {
 	var photo models.Photo
 	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&photo)
@@ -121,6 +549,7 @@ func (r *PhotoRepository) FindByID(ctx context.Context, id primitive.ObjectID) (
 	return &photo, nil
 }
 
+// Update 更新图片字段；更新了标题、描述或标签时同步重建检索词
 func (r *PhotoRepository) Update(ctx context.Context, id primitive.ObjectID, update bson.M) error {
 	update["updated_at"] = primitive.NewDateTimeFromTime(time.Now())
 
@@ -129,6 +558,28 @@ func (r *PhotoRepository) Update(ctx context.Context, id primitive.ObjectID, upd
 		bson.M{"_id": id},
 		bson.M{"$set": update},
 	)
+	if err != nil {
+		return err
+	}
+	for _, field := range []string{"title", "description", "tags"} {
+		if _, ok := update[field]; ok {
+			return r.RefreshSearchIndex(ctx, id)
+		}
+	}
+	return nil
+}
+
+// RefreshSearchIndex 按图片当前的标题、描述与标签重建检索词
+func (r *PhotoRepository) RefreshSearchIndex(ctx context.Context, id primitive.ObjectID) error {
+	var photo models.Photo
+	opts := options.FindOne().SetProjection(bson.M{"title": 1, "description": 1, "tags": 1})
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}, opts).Decode(&photo); err != nil {
+		return err
+	}
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"search": textsearch.BuildIndex(photo.Title, photo.Description, photo.Tags)}},
+	)
 	return err
 }
 
@@ -137,6 +588,145 @@ func (r *PhotoRepository) Delete(ctx context.Context, id primitive.ObjectID) err
 	return err
 }
 
+// MoveToTrash 标记图片为已删除（仅对未删除的图片生效），返回是否更新
+func (r *PhotoRepository) MoveToTrash(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{
+			"deleted_at": primitive.NewDateTimeFromTime(at),
+			"updated_at": primitive.NewDateTimeFromTime(time.Now()),
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// Restore 从回收站恢复图片，返回是否更新
+func (r *PhotoRepository) Restore(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deleted_at": bson.M{"$exists": true}},
+		bson.M{
+			"$unset": bson.M{"deleted_at": ""},
+			"$set":   bson.M{"updated_at": primitive.NewDateTimeFromTime(time.Now())},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// FindTrashedIDs 返回 ids 中已移入回收站的图片 ID
+func (r *PhotoRepository) FindTrashedIDs(ctx context.Context, ids []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	filter := bson.M{"_id": bson.M{"$in": ids}, "deleted_at": bson.M{"$exists": true}}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	out := make([]primitive.ObjectID, 0, len(docs))
+	for _, d := range docs {
+		out = append(out, d.ID)
+	}
+	return out, nil
+}
+
 func (r *PhotoRepository) CountByFileName(ctx context.Context, fileName string) (int64, error) {
 	return r.collection.CountDocuments(ctx, bson.M{"file_name": fileName})
 }
+
+// ForEach 按 _id 顺序遍历匹配的图片（用于迁移/回填等批处理，避免一次性加载全部文档）
+func (r *PhotoRepository) ForEach(ctx context.Context, filter bson.M, fn func(*models.Photo) error) error {
+	if filter == nil {
+		filter = bson.M{}
+	}
+	// 较小的批次避免逐条处理耗时较长时游标超时
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetBatchSize(50)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var photo models.Photo
+		if err := cursor.Decode(&photo); err != nil {
+			return err
+		}
+		if err := fn(&photo); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// UpdateByFileName 更新共用同一文件（秒传副本）的所有记录，返回更新数量
+func (r *PhotoRepository) UpdateByFileName(ctx context.Context, fileName string, update bson.M) (int64, error) {
+	update["updated_at"] = primitive.NewDateTimeFromTime(time.Now())
+
+	result, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"file_name": fileName},
+		bson.M{"$set": update},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// FindPerceptualHashes 返回用户所有带感知哈希的图片（仅 _id/phash/created_at 字段）
+func (r *PhotoRepository) FindPerceptualHashes(ctx context.Context, userID primitive.ObjectID) ([]*models.Photo, error) {
+	filter := bson.M{
+		"user_id":    userID,
+		"phash":      bson.M{"$exists": true, "$ne": ""},
+		"deleted_at": bson.M{"$exists": false},
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1, "phash": 1, "created_at": 1}).
+		SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var photos []*models.Photo
+	if err = cursor.All(ctx, &photos); err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+// FindByIDs 按 ID 批量查询（结果顺序不保证与 ids 一致）
+func (r *PhotoRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Photo, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var photos []*models.Photo
+	if err = cursor.All(ctx, &photos); err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
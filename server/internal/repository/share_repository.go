@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"photoms/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ShareRepository struct {
+	collection *mongo.Collection
+}
+
+func NewShareRepository(db *mongo.Database) *ShareRepository {
+	return &ShareRepository{
+		collection: db.Collection("shares"),
+	}
+}
+
+// EnsureIndexes 创建分享链接所需的索引（Token 唯一）
+func (r *ShareRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+func (r *ShareRepository) Create(ctx context.Context, share *models.Share) error {
+	now := primitive.NewDateTimeFromTime(time.Now())
+	share.CreatedAt = now
+	share.UpdatedAt = share.CreatedAt
+
+	result, err := r.collection.InsertOne(ctx, share)
+	if err != nil {
+		return err
+	}
+
+	share.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *ShareRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Share, error) {
+	var share models.Share
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&share)
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+func (r *ShareRepository) FindByToken(ctx context.Context, token string) (*models.Share, error) {
+	var share models.Share
+	err := r.collection.FindOne(ctx, bson.M{"token": token}).Decode(&share)
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// FindByUserID 分页查询用户创建的分享链接（最新的在前）
+func (r *ShareRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID, page, limit int64) ([]*models.Share, int64, error) {
+	filter := bson.M{"user_id": userID}
+	opts := options.Find().
+		SetSkip((page - 1) * limit).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var shares []*models.Share
+	if err = cursor.All(ctx, &shares); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return shares, total, nil
+}
+
+// IncrementViews 访问计数 +1 并记录最近访问时间
+func (r *ShareRepository) IncrementViews(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$inc": bson.M{"views": 1},
+			"$set": bson.M{"last_viewed_at": primitive.NewDateTimeFromTime(time.Now())},
+		},
+	)
+	return err
+}
+
+func (r *ShareRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
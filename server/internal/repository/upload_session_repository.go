@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"photoms/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type UploadSessionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewUploadSessionRepository(db *mongo.Database) *UploadSessionRepository {
+	return &UploadSessionRepository{
+		collection: db.Collection("upload_sessions"),
+	}
+}
+
+func (r *UploadSessionRepository) Create(ctx context.Context, session *models.UploadSession) error {
+	now := primitive.NewDateTimeFromTime(time.Now())
+	session.CreatedAt = now
+	session.UpdatedAt = session.CreatedAt
+
+	result, err := r.collection.InsertOne(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	session.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *UploadSessionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.UploadSession, error) {
+	var session models.UploadSession
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&session)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// AdvanceOffset 仅当当前 offset 等于 expected 时才更新，防止并发写入同一会话
+func (r *UploadSessionRepository) AdvanceOffset(ctx context.Context, id primitive.ObjectID, expected, offset int64, expiresAt time.Time) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "offset": expected},
+		bson.M{"$set": bson.M{
+			"offset":     offset,
+			"expires_at": primitive.NewDateTimeFromTime(expiresAt),
+			"updated_at": primitive.NewDateTimeFromTime(time.Now()),
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// SetPhotoID 记录完成时将要创建的图片 ID（会话仍为上传中），用于完成操作失败后的重试
+func (r *UploadSessionRepository) SetPhotoID(ctx context.Context, id, photoID primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			"photo_id":   photoID,
+			"updated_at": primitive.NewDateTimeFromTime(time.Now()),
+		}},
+	)
+	return err
+}
+
+// MarkCompleted 标记会话完成；expiresAt 为完成后保留会话记录（供重复调用完成接口）的截止时间
+func (r *UploadSessionRepository) MarkCompleted(ctx context.Context, id, photoID primitive.ObjectID, expiresAt time.Time) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			"status":     "COMPLETED",
+			"photo_id":   photoID,
+			"expires_at": primitive.NewDateTimeFromTime(expiresAt),
+			"updated_at": primitive.NewDateTimeFromTime(time.Now()),
+		}},
+	)
+	return err
+}
+
+func (r *UploadSessionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// FindExpired 返回在 before 之前过期的会话
+func (r *UploadSessionRepository) FindExpired(ctx context.Context, before time.Time) ([]*models.UploadSession, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"expires_at": bson.M{"$lt": primitive.NewDateTimeFromTime(before)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []*models.UploadSession
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
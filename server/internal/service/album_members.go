@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"photoms/internal/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// albumRoleRank 角色权限由低到高
+var albumRoleRank = map[string]int{
+	models.AlbumRoleViewer:      1,
+	models.AlbumRoleContributor: 2,
+	models.AlbumRoleEditor:      3,
+	models.AlbumRoleOwner:       4,
+}
+
+// AddMember 按邮箱添加共享成员（仅所有者）；用户已是成员时更新其角色
+func (s *AlbumService) AddMember(ctx context.Context, albumID, userID primitive.ObjectID, email, role string) (*AlbumView, error) {
+	album, err := s.getAlbum(ctx, albumID, userID, models.AlbumRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	role, err = normalizeMemberRole(role)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, strings.TrimSpace(email))
+	if err != nil || user == nil {
+		return nil, ErrAlbumUserNotFound
+	}
+	if user.ID == album.UserID {
+		return nil, ErrAlbumMemberIsOwner
+	}
+
+	added, err := s.albumRepo.AddMember(ctx, album.ID, models.AlbumMember{
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     role,
+		AddedAt:  primitive.NewDateTimeFromTime(time.Now()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add member: %w", err)
+	}
+	if !added {
+		if _, err := s.albumRepo.SetMemberRole(ctx, album.ID, user.ID, role); err != nil {
+			return nil, fmt.Errorf("failed to update member role: %w", err)
+		}
+	}
+	return s.reload(ctx, album.ID, userID)
+}
+
+// UpdateMemberRole 修改成员角色（仅所有者）
+func (s *AlbumService) UpdateMemberRole(ctx context.Context, albumID, userID, memberID primitive.ObjectID, role string) (*AlbumView, error) {
+	album, err := s.getAlbum(ctx, albumID, userID, models.AlbumRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	role, err = normalizeMemberRole(role)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.albumRepo.SetMemberRole(ctx, album.ID, memberID, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update member role: %w", err)
+	}
+	if !ok {
+		return nil, ErrAlbumMemberNotFound
+	}
+	return s.reload(ctx, album.ID, userID)
+}
+
+// RemoveMember 移除成员：所有者可移除任何成员，成员可以退出（移除自己）
+func (s *AlbumService) RemoveMember(ctx context.Context, albumID, userID, memberID primitive.ObjectID) error {
+	minRole := models.AlbumRoleOwner
+	if memberID == userID {
+		minRole = models.AlbumRoleViewer
+	}
+	album, err := s.getAlbum(ctx, albumID, userID, minRole)
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.albumRepo.RemoveMember(ctx, album.ID, memberID)
+	if err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+	if !ok {
+		return ErrAlbumMemberNotFound
+	}
+	return nil
+}
+
+// albumRole 返回用户在相册中的角色：所有者为 owner，非成员为空
+func albumRole(album *models.Album, userID primitive.ObjectID) string {
+	if album.UserID == userID {
+		return models.AlbumRoleOwner
+	}
+	for _, m := range album.Members {
+		if m.UserID == userID {
+			return m.Role
+		}
+	}
+	return ""
+}
+
+func roleAtLeast(role, minRole string) bool {
+	return albumRoleRank[role] >= albumRoleRank[minRole]
+}
+
+func normalizeMemberRole(role string) (string, error) {
+	role = strings.ToLower(strings.TrimSpace(role))
+	switch role {
+	case models.AlbumRoleViewer, models.AlbumRoleContributor, models.AlbumRoleEditor:
+		return role, nil
+	default:
+		return "", ErrInvalidAlbumRole
+	}
+}
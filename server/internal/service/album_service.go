@@ -0,0 +1,532 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"photoms/internal/models"
+	"photoms/internal/repository"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrAlbumNotFound      = errors.New("album not found")
+	ErrAlbumForbidden     = errors.New("unauthorized: album belongs to another user")
+	ErrAlbumTitleRequired = errors.New("album title is required")
+	ErrAlbumInvalidPhotos = errors.New("photos not found, in trash or belong to another user")
+	ErrPhotoNotInAlbum    = errors.New("photo is not in album")
+	ErrAlbumOrderMismatch = errors.New("photoIds must contain exactly the photos in the album")
+	ErrAlbumTooManyPhotos = errors.New("too many photos in one request")
+
+	ErrAlbumPermissionDenied = errors.New("unauthorized: insufficient album role")
+	ErrInvalidAlbumRole      = errors.New("role must be viewer, contributor or editor")
+	ErrAlbumUserNotFound     = errors.New("user not found")
+	ErrAlbumMemberNotFound   = errors.New("user is not a member of this album")
+	ErrAlbumMemberIsOwner    = errors.New("album owner cannot be added as a member")
+)
+
+// maxAlbumPhotosPerBatch 单次添加到相册的最大图片数
+const maxAlbumPhotosPerBatch = 1000
+
+// AlbumView 相册的 API 视图：附带图片数量、封面图片与当前用户的角色
+type AlbumView struct {
+	*models.Album
+	PhotoCount int           `json:"photoCount"`
+	Cover      *models.Photo `json:"cover,omitempty"`
+	Role       string        `json:"role"`
+}
+
+type AlbumService struct {
+	albumRepo *repository.AlbumRepository
+	photoRepo *repository.PhotoRepository
+	userRepo  *repository.UserRepository
+}
+
+func NewAlbumService(albumRepo *repository.AlbumRepository, photoRepo *repository.PhotoRepository, userRepo *repository.UserRepository) *AlbumService {
+	return &AlbumService{albumRepo: albumRepo, photoRepo: photoRepo, userRepo: userRepo}
+}
+
+// CreateAlbum 创建相册；query 非 nil 时创建智能相册
+func (s *AlbumService) CreateAlbum(ctx context.Context, userID primitive.ObjectID, title, description string, query *models.SmartQuery) (*AlbumView, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, ErrAlbumTitleRequired
+	}
+
+	album := &models.Album{
+		UserID:      userID,
+		Type:        models.AlbumTypeManual,
+		Title:       title,
+		Description: strings.TrimSpace(description),
+	}
+	if query != nil {
+		q, err := normalizeSmartQuery(query)
+		if err != nil {
+			return nil, err
+		}
+		album.Type = models.AlbumTypeSmart
+		album.Query = q
+	}
+	if err := s.albumRepo.Create(ctx, album); err != nil {
+		return nil, err
+	}
+	return s.view(ctx, album, userID)
+}
+
+// GetAlbum 获取相册（所有者或任意角色的成员）
+func (s *AlbumService) GetAlbum(ctx context.Context, albumID, userID primitive.ObjectID) (*AlbumView, error) {
+	album, err := s.getAlbum(ctx, albumID, userID, models.AlbumRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return s.view(ctx, album, userID)
+}
+
+// ListAlbums 分页列出相册（albumType 为 manual/smart 时只列出该类型），智能相册的数量与封面实时计算
+func (s *AlbumService) ListAlbums(ctx context.Context, userID primitive.ObjectID, albumType string, page, limit int64) ([]*AlbumView, int64, error) {
+	albums, total, err := s.albumRepo.FindByUserID(ctx, userID, albumType, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	views, err := s.views(ctx, albums, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return views, total, nil
+}
+
+// ListSharedAlbums 分页列出他人共享给当前用户的相册
+func (s *AlbumService) ListSharedAlbums(ctx context.Context, userID primitive.ObjectID, page, limit int64) ([]*AlbumView, int64, error) {
+	albums, total, err := s.albumRepo.FindSharedWith(ctx, userID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	views, err := s.views(ctx, albums, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return views, total, nil
+}
+
+// UpdateAlbum 更新相册信息（仅允许更新标题、描述，以及智能相册的查询条件；需要 editor 角色）
+func (s *AlbumService) UpdateAlbum(ctx context.Context, albumID, userID primitive.ObjectID, title, description *string, query *models.SmartQuery) (*AlbumView, error) {
+	album, err := s.getAlbum(ctx, albumID, userID, models.AlbumRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{}
+	if title != nil {
+		t := strings.TrimSpace(*title)
+		if t == "" {
+			return nil, ErrAlbumTitleRequired
+		}
+		update["title"] = t
+	}
+	if description != nil {
+		update["description"] = strings.TrimSpace(*description)
+	}
+	if query != nil {
+		if !isSmartAlbum(album) {
+			return nil, fmt.Errorf("%w: only smart albums have a query", ErrInvalidSmartQuery)
+		}
+		q, err := normalizeSmartQuery(query)
+		if err != nil {
+			return nil, err
+		}
+		update["query"] = q
+	}
+	if len(update) == 0 {
+		return s.view(ctx, album, userID)
+	}
+
+	if err := s.albumRepo.Update(ctx, album.ID, update); err != nil {
+		return nil, fmt.Errorf("failed to update album: %w", err)
+	}
+	return s.reload(ctx, album.ID, userID)
+}
+
+// DeleteAlbum 删除相册（仅所有者，不删除其中的图片）
+func (s *AlbumService) DeleteAlbum(ctx context.Context, albumID, userID primitive.ObjectID) error {
+	album, err := s.getAlbum(ctx, albumID, userID, models.AlbumRoleOwner)
+	if err != nil {
+		return err
+	}
+	return s.albumRepo.Delete(ctx, album.ID)
+}
+
+// AddPhotos 将自己的图片追加到相册末尾（需要 contributor 角色）
+func (s *AlbumService) AddPhotos(ctx context.Context, albumID, userID primitive.ObjectID, photoIDs []primitive.ObjectID) (*AlbumView, error) {
+	album, err := s.getManualAlbum(ctx, albumID, userID, models.AlbumRoleContributor)
+	if err != nil {
+		return nil, err
+	}
+	photoIDs = uniqueObjectIDs(photoIDs)
+	if len(photoIDs) == 0 {
+		return s.view(ctx, album, userID)
+	}
+	if len(photoIDs) > maxAlbumPhotosPerBatch {
+		return nil, ErrAlbumTooManyPhotos
+	}
+	if err := s.checkPhotosOwned(ctx, userID, photoIDs); err != nil {
+		return nil, err
+	}
+
+	if err := s.albumRepo.AddPhotos(ctx, album.ID, photoIDs); err != nil {
+		return nil, fmt.Errorf("failed to add photos: %w", err)
+	}
+	return s.reload(ctx, album.ID, userID)
+}
+
+// RemovePhotos 从相册移除图片（不删除图片本身；需要 editor 角色）
+func (s *AlbumService) RemovePhotos(ctx context.Context, albumID, userID primitive.ObjectID, photoIDs []primitive.ObjectID) (*AlbumView, error) {
+	album, err := s.getManualAlbum(ctx, albumID, userID, models.AlbumRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	photoIDs = uniqueObjectIDs(photoIDs)
+	if len(photoIDs) == 0 {
+		return s.view(ctx, album, userID)
+	}
+
+	if err := s.albumRepo.RemovePhotos(ctx, album.ID, photoIDs); err != nil {
+		return nil, fmt.Errorf("failed to remove photos: %w", err)
+	}
+	return s.reload(ctx, album.ID, userID)
+}
+
+// removeFromOtherAlbums 将图片从除 albumID 以外、用户有 editor 角色的手动相册中移除；
+// 无编辑权限的相册（如他人相册中仅为 contributor）保持不变
+func (s *AlbumService) removeFromOtherAlbums(ctx context.Context, albumID, userID primitive.ObjectID, photoIDs []primitive.ObjectID) error {
+	albums, err := s.albumRepo.FindAccessibleWithPhotos(ctx, photoIDs, userID)
+	if err != nil {
+		return fmt.Errorf("failed to find albums: %w", err)
+	}
+	for _, album := range albums {
+		if album.ID == albumID || isSmartAlbum(album) || !roleAtLeast(albumRole(album, userID), models.AlbumRoleEditor) {
+			continue
+		}
+		if err := s.albumRepo.RemovePhotos(ctx, album.ID, photoIDs); err != nil {
+			return fmt.Errorf("failed to remove photos from album %s: %w", album.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// ReorderPhotos 手动排序：photoIDs 必须恰好是相册中的全部图片（不含回收站中的图片，它们保持在末尾）。
+// 需要 editor 角色
+func (s *AlbumService) ReorderPhotos(ctx context.Context, albumID, userID primitive.ObjectID, photoIDs []primitive.ObjectID) (*AlbumView, error) {
+	album, err := s.getManualAlbum(ctx, albumID, userID, models.AlbumRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	trashedIDs, err := s.photoRepo.FindTrashedIDs(ctx, album.PhotoIDs)
+	if err != nil {
+		return nil, err
+	}
+	trashed := make(map[primitive.ObjectID]struct{}, len(trashedIDs))
+	for _, id := range trashedIDs {
+		trashed[id] = struct{}{}
+	}
+
+	current := make(map[primitive.ObjectID]struct{}, len(album.PhotoIDs))
+	var trailing []primitive.ObjectID
+	for _, id := range album.PhotoIDs {
+		if _, ok := trashed[id]; ok {
+			trailing = append(trailing, id)
+			continue
+		}
+		current[id] = struct{}{}
+	}
+	if len(uniqueObjectIDs(photoIDs)) != len(photoIDs) || len(photoIDs) != len(current) {
+		return nil, ErrAlbumOrderMismatch
+	}
+	for _, id := range photoIDs {
+		if _, ok := current[id]; !ok {
+			return nil, ErrAlbumOrderMismatch
+		}
+	}
+	if len(photoIDs) == 0 {
+		return s.view(ctx, album, userID)
+	}
+
+	order := append(append([]primitive.ObjectID{}, photoIDs...), trailing...)
+	ok, err := s.albumRepo.ReorderPhotos(ctx, album.ID, order)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reorder photos: %w", err)
+	}
+	if !ok {
+		// 期间相册内容被修改
+		return nil, ErrAlbumOrderMismatch
+	}
+	return s.reload(ctx, album.ID, userID)
+}
+
+// SetCover 设置封面（必须是相册中的图片）；photoID 为 nil 时恢复默认（第一张）。需要 editor 角色
+func (s *AlbumService) SetCover(ctx context.Context, albumID, userID primitive.ObjectID, photoID *primitive.ObjectID) (*AlbumView, error) {
+	album, err := s.getManualAlbum(ctx, albumID, userID, models.AlbumRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	if photoID == nil {
+		if err := s.albumRepo.Update(ctx, album.ID, bson.M{"cover_photo_id": nil}); err != nil {
+			return nil, fmt.Errorf("failed to update cover: %w", err)
+		}
+		return s.reload(ctx, album.ID, userID)
+	}
+
+	if !containsObjectID(album.PhotoIDs, *photoID) {
+		return nil, ErrPhotoNotInAlbum
+	}
+	// 相册中可能有其他成员的图片，封面只要求图片未被删除
+	photos, err := s.photoRepo.FindByIDs(ctx, []primitive.ObjectID{*photoID})
+	if err != nil {
+		return nil, err
+	}
+	if len(photos) == 0 || photos[0].DeletedAt != nil {
+		return nil, ErrAlbumInvalidPhotos
+	}
+	if err := s.albumRepo.Update(ctx, album.ID, bson.M{"cover_photo_id": *photoID}); err != nil {
+		return nil, fmt.Errorf("failed to update cover: %w", err)
+	}
+	return s.reload(ctx, album.ID, userID)
+}
+
+// ListAlbumPhotos 按相册排序分页列出图片，支持与图片列表相同的过滤条件
+func (s *AlbumService) ListAlbumPhotos(ctx context.Context, albumID, userID primitive.ObjectID, filter repository.PhotoFilter, page, limit int64) ([]*models.Photo, int64, error) {
+	album, err := s.getAlbum(ctx, albumID, userID, models.AlbumRoleViewer)
+	if err != nil {
+		return nil, 0, err
+	}
+	if isSmartAlbum(album) {
+		smart := smartAlbumFilter(album)
+		filter.UserID = &album.UserID
+		filter.Within = &smart
+		photos, total, err := s.photoRepo.Find(ctx, filter, page, limit)
+		if err != nil {
+			return nil, 0, err
+		}
+		highlightPhotos(photos, filter.Q)
+		return photos, total, nil
+	}
+	if len(album.PhotoIDs) == 0 {
+		return []*models.Photo{}, 0, nil
+	}
+
+	// 手动相册可能包含成员添加的图片，只按相册内容限定
+	filter.UserID = nil
+	filter.IDs = album.PhotoIDs
+	photos, total, err := s.photoRepo.FindOrdered(ctx, filter, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	highlightPhotos(photos, filter.Q)
+	return photos, total, nil
+}
+
+// ContainsPhoto 判断图片是否属于相册（智能相册按查询条件判断）
+func (s *AlbumService) ContainsPhoto(ctx context.Context, albumID, userID, photoID primitive.ObjectID) (bool, error) {
+	album, err := s.getAlbum(ctx, albumID, userID, models.AlbumRoleViewer)
+	if err != nil {
+		return false, err
+	}
+	if !isSmartAlbum(album) {
+		return containsObjectID(album.PhotoIDs, photoID), nil
+	}
+
+	smart := smartAlbumFilter(album)
+	_, total, err := s.photoRepo.Find(ctx, repository.PhotoFilter{
+		UserID: &album.UserID,
+		IDs:    []primitive.ObjectID{photoID},
+		Within: &smart,
+	}, 1, 1)
+	if err != nil {
+		return false, err
+	}
+	return total > 0, nil
+}
+
+// getAlbum 获取相册并校验用户角色不低于 minRole；非所有者且非成员返回 ErrAlbumForbidden
+func (s *AlbumService) getAlbum(ctx context.Context, albumID, userID primitive.ObjectID, minRole string) (*models.Album, error) {
+	album, err := s.albumRepo.FindByID(ctx, albumID)
+	if err != nil {
+		return nil, ErrAlbumNotFound
+	}
+	role := albumRole(album, userID)
+	if role == "" {
+		return nil, ErrAlbumForbidden
+	}
+	if !roleAtLeast(role, minRole) {
+		return nil, ErrAlbumPermissionDenied
+	}
+	return album, nil
+}
+
+// getManualAlbum 获取手动相册（智能相册的图片由查询决定，不能手动编辑）
+func (s *AlbumService) getManualAlbum(ctx context.Context, albumID, userID primitive.ObjectID, minRole string) (*models.Album, error) {
+	album, err := s.getAlbum(ctx, albumID, userID, minRole)
+	if err != nil {
+		return nil, err
+	}
+	if isSmartAlbum(album) {
+		return nil, ErrSmartAlbumReadOnly
+	}
+	return album, nil
+}
+
+// checkPhotosOwned 校验图片均属于该用户且不在回收站中
+func (s *AlbumService) checkPhotosOwned(ctx context.Context, userID primitive.ObjectID, photoIDs []primitive.ObjectID) error {
+	photos, err := s.photoRepo.FindByIDs(ctx, photoIDs)
+	if err != nil {
+		return err
+	}
+	owned := 0
+	for _, p := range photos {
+		if p.UserID == userID && p.DeletedAt == nil {
+			owned++
+		}
+	}
+	if owned != len(photoIDs) {
+		return ErrAlbumInvalidPhotos
+	}
+	return nil
+}
+
+func (s *AlbumService) reload(ctx context.Context, albumID, userID primitive.ObjectID) (*AlbumView, error) {
+	album, err := s.albumRepo.FindByID(ctx, albumID)
+	if err != nil {
+		return nil, ErrAlbumNotFound
+	}
+	return s.view(ctx, album, userID)
+}
+
+func (s *AlbumService) view(ctx context.Context, album *models.Album, userID primitive.ObjectID) (*AlbumView, error) {
+	views, err := s.views(ctx, []*models.Album{album}, userID)
+	if err != nil {
+		return nil, err
+	}
+	return views[0], nil
+}
+
+// views 批量组装相册视图，手动相册的封面图片一次查询（回收站中的图片不计数、不作封面）；
+// 智能相册按查询实时计算数量，封面为最新匹配的图片
+func (s *AlbumService) views(ctx context.Context, albums []*models.Album, userID primitive.ObjectID) ([]*AlbumView, error) {
+	var allIDs []primitive.ObjectID
+	for _, album := range albums {
+		if !isSmartAlbum(album) {
+			allIDs = append(allIDs, album.PhotoIDs...)
+		}
+	}
+	trashedIDs, err := s.photoRepo.FindTrashedIDs(ctx, uniqueObjectIDs(allIDs))
+	if err != nil {
+		return nil, err
+	}
+	trashed := make(map[primitive.ObjectID]struct{}, len(trashedIDs))
+	for _, id := range trashedIDs {
+		trashed[id] = struct{}{}
+	}
+
+	coverIDs := make([]primitive.ObjectID, 0, len(albums))
+	for _, album := range albums {
+		if isSmartAlbum(album) {
+			continue
+		}
+		if id, ok := albumCoverID(album, trashed); ok {
+			coverIDs = append(coverIDs, id)
+		}
+	}
+
+	covers := make(map[primitive.ObjectID]*models.Photo, len(coverIDs))
+	if len(coverIDs) > 0 {
+		photos, err := s.photoRepo.FindByIDs(ctx, coverIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range photos {
+			covers[p.ID] = p
+		}
+	}
+
+	views := make([]*AlbumView, 0, len(albums))
+	for _, album := range albums {
+		if album.PhotoIDs == nil {
+			album.PhotoIDs = []primitive.ObjectID{}
+		}
+		if album.Members == nil {
+			album.Members = []models.AlbumMember{}
+		}
+		if isSmartAlbum(album) {
+			photos, total, err := s.photoRepo.Find(ctx, smartAlbumFilter(album), 1, 1)
+			if err != nil {
+				return nil, err
+			}
+			view := &AlbumView{Album: album, PhotoCount: int(total), Role: albumRole(album, userID)}
+			if len(photos) > 0 {
+				view.Cover = photos[0]
+			}
+			views = append(views, view)
+			continue
+		}
+
+		if album.Type == "" {
+			album.Type = models.AlbumTypeManual
+		}
+		count := 0
+		for _, id := range album.PhotoIDs {
+			if _, ok := trashed[id]; !ok {
+				count++
+			}
+		}
+		view := &AlbumView{Album: album, PhotoCount: count, Role: albumRole(album, userID)}
+		if id, ok := albumCoverID(album, trashed); ok {
+			view.Cover = covers[id]
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// albumCoverID 相册封面：已设置且不在回收站时使用设置的封面，否则为第一张未删除的图片
+func albumCoverID(album *models.Album, trashed map[primitive.ObjectID]struct{}) (primitive.ObjectID, bool) {
+	if album.CoverPhotoID != nil {
+		if _, ok := trashed[*album.CoverPhotoID]; !ok {
+			return *album.CoverPhotoID, true
+		}
+	}
+	for _, id := range album.PhotoIDs {
+		if _, ok := trashed[id]; !ok {
+			return id, true
+		}
+	}
+	return primitive.NilObjectID, false
+}
+
+func uniqueObjectIDs(ids []primitive.ObjectID) []primitive.ObjectID {
+	seen := make(map[primitive.ObjectID]struct{}, len(ids))
+	out := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if id.IsZero() {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	return out
+}
+
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
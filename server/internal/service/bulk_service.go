@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"photoms/internal/models"
+	"photoms/internal/repository"
+	"photoms/pkg/ai"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrBulkNoTarget         = errors.New("exactly one of ids or filter is required")
+	ErrBulkNoOperation      = errors.New("at least one operation is required")
+	ErrBulkTooManyPhotos    = errors.New("too many photos in one bulk request")
+	ErrInvalidBulkOperation = errors.New("invalid bulk operation")
+)
+
+// maxBulkPhotos 单次批量操作的最大图片数（通过过滤条件选择时，匹配数超过该值直接拒绝）
+const maxBulkPhotos = maxAlbumPhotosPerBatch
+
+const (
+	BulkStatusSucceeded = "succeeded"
+	BulkStatusFailed    = "failed"
+	// BulkStatusPartial 部分操作已生效后失败，已生效的操作见 BulkResult.Applied
+	BulkStatusPartial = "partial"
+)
+
+// 批量操作名称，与请求中的字段名一致，用于 BulkResult.Applied
+const (
+	BulkOpAddTags        = "addTags"
+	BulkOpRemoveTags     = "removeTags"
+	BulkOpSetDescription = "setDescription"
+	BulkOpMoveToAlbum    = "moveToAlbum"
+	BulkOpAITags         = "aiTags"
+	BulkOpTrash          = "trash"
+)
+
+// BulkOperations 批量操作集合，可同时指定多项，按 移动到相册 → 标签/描述 → AI 标注 → 移入回收站 的顺序执行
+type BulkOperations struct {
+	AddTags     []string
+	RemoveTags  []string
+	Description *string
+	AlbumID     *primitive.ObjectID // 移动到该手动相册（需要 contributor 角色），并从用户可编辑的其他手动相册中移除
+	AITags      bool                // 重新生成 AI 标签（后台异步执行）
+	Trash       bool
+}
+
+// BulkTarget 批量操作的目标图片：按 ID 列表或按查询条件（仅当前用户未删除的图片），二选一
+type BulkTarget struct {
+	PhotoIDs []primitive.ObjectID
+	Filter   *models.SmartQuery
+}
+
+// BulkResult 单张图片的处理结果
+type BulkResult struct {
+	PhotoID primitive.ObjectID `json:"photoId"`
+	Status  string             `json:"status"`            // "succeeded" / "failed" / "partial"
+	Applied []string           `json:"applied,omitempty"` // 已生效的操作
+	Error   string             `json:"error,omitempty"`
+}
+
+type BulkService struct {
+	photoRepo    *repository.PhotoRepository
+	photoService *PhotoService
+	albumService *AlbumService
+}
+
+func NewBulkService(photoRepo *repository.PhotoRepository, photoService *PhotoService, albumService *AlbumService) *BulkService {
+	return &BulkService{
+		photoRepo:    photoRepo,
+		photoService: photoService,
+		albumService: albumService,
+	}
+}
+
+// Apply 对目标图片执行批量操作，仅处理当前用户自己的图片；单张失败不影响其他图片。
+// 请求本身无效（无目标、无操作、相册无权限等）时整体返回错误，返回结果与目标顺序一致。
+// 写入任何修改前先校验全部图片；某张图片在部分操作生效后失败时状态为 partial。
+func (s *BulkService) Apply(ctx context.Context, userID primitive.ObjectID, target BulkTarget, ops BulkOperations) ([]BulkResult, error) {
+	ops, err := s.normalizeOperations(ctx, userID, ops)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := s.resolveTarget(ctx, userID, target)
+	if err != nil {
+		return nil, err
+	}
+
+	photos, err := s.photoRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load photos: %w", err)
+	}
+	byID := make(map[primitive.ObjectID]*models.Photo, len(photos))
+	for _, photo := range photos {
+		byID[photo.ID] = photo
+	}
+
+	results := make([]BulkResult, len(ids))
+	pending := make([]int, 0, len(ids))
+	for i, id := range ids {
+		results[i] = BulkResult{PhotoID: id, Status: BulkStatusSucceeded}
+		photo := byID[id]
+		switch {
+		case photo == nil:
+			results[i].fail(fmt.Errorf("photo not found"))
+		case photo.UserID != userID:
+			results[i].fail(fmt.Errorf("unauthorized: photo belongs to another user"))
+		case photo.DeletedAt != nil:
+			results[i].fail(fmt.Errorf("photo not found: %w", ErrPhotoInTrash))
+		default:
+			pending = append(pending, i)
+		}
+	}
+
+	// 相册操作对所有图片一次完成，放在逐张写入之前，失败时尚未修改任何图片
+	if ops.AlbumID != nil && len(pending) > 0 {
+		albumIDs := make([]primitive.ObjectID, 0, len(pending))
+		for _, i := range pending {
+			albumIDs = append(albumIDs, ids[i])
+		}
+		if _, err := s.albumService.AddPhotos(ctx, *ops.AlbumID, userID, albumIDs); err != nil {
+			failAll(results, pending, err)
+			pending = nil
+		} else if err := s.albumService.removeFromOtherAlbums(ctx, *ops.AlbumID, userID, albumIDs); err != nil {
+			failAll(results, pending, fmt.Errorf("added to album but not removed from other albums: %w", err))
+			pending = nil
+		}
+		for _, i := range pending {
+			results[i].Applied = append(results[i].Applied, BulkOpMoveToAlbum)
+		}
+	}
+
+	kept := pending[:0]
+	for _, i := range pending {
+		applied, err := s.updateFields(ctx, byID[ids[i]], ops)
+		if err != nil {
+			results[i].fail(err)
+			continue
+		}
+		results[i].Applied = append(results[i].Applied, applied...)
+		kept = append(kept, i)
+	}
+	pending = kept
+
+	if ops.AITags {
+		queued := make([]primitive.ObjectID, 0, len(pending))
+		for _, i := range pending {
+			queued = append(queued, ids[i])
+			results[i].Applied = append(results[i].Applied, BulkOpAITags)
+		}
+		s.photoService.queueAITags(queued, userID)
+	}
+
+	if ops.Trash {
+		now := time.Now()
+		for _, i := range pending {
+			ok, err := s.photoRepo.MoveToTrash(ctx, ids[i], now)
+			if err != nil {
+				results[i].fail(fmt.Errorf("failed to move photo to trash: %w", err))
+			} else if !ok {
+				results[i].fail(fmt.Errorf("photo not found: %w", ErrPhotoInTrash))
+			} else {
+				results[i].Applied = append(results[i].Applied, BulkOpTrash)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+func failAll(results []BulkResult, indexes []int, err error) {
+	for _, i := range indexes {
+		results[i].fail(err)
+	}
+}
+
+// normalizeOperations 清理标签并校验操作组合；加入相册时预先检查相册权限
+func (s *BulkService) normalizeOperations(ctx context.Context, userID primitive.ObjectID, ops BulkOperations) (BulkOperations, error) {
+	ops.AddTags = cleanTagNames(ops.AddTags)
+	ops.RemoveTags = cleanTagNames(ops.RemoveTags)
+
+	if len(ops.AddTags) == 0 && len(ops.RemoveTags) == 0 && ops.Description == nil &&
+		ops.AlbumID == nil && !ops.AITags && !ops.Trash {
+		return ops, ErrBulkNoOperation
+	}
+	if ops.Trash && (ops.AlbumID != nil || ops.AITags) {
+		return ops, fmt.Errorf("%w: trash cannot be combined with album or aiTags", ErrInvalidBulkOperation)
+	}
+	for _, name := range ops.AddTags {
+		if containsTagName(ops.RemoveTags, name) {
+			return ops, fmt.Errorf("%w: tag %q is both added and removed", ErrInvalidBulkOperation, name)
+		}
+	}
+
+	if ops.AITags {
+		cfg := s.photoService.config
+		if cfg == nil || !cfg.AITaggingEnabled {
+			return ops, ai.ErrDisabled
+		}
+		if s.photoService.tagger == nil {
+			return ops, ai.ErrNotConfigured
+		}
+	}
+	if ops.AlbumID != nil {
+		if _, err := s.albumService.getManualAlbum(ctx, *ops.AlbumID, userID, models.AlbumRoleContributor); err != nil {
+			return ops, err
+		}
+	}
+	return ops, nil
+}
+
+// resolveTarget 返回去重后的目标图片 ID
+func (s *BulkService) resolveTarget(ctx context.Context, userID primitive.ObjectID, target BulkTarget) ([]primitive.ObjectID, error) {
+	if (len(target.PhotoIDs) == 0) == (target.Filter == nil) {
+		return nil, ErrBulkNoTarget
+	}
+
+	if target.Filter == nil {
+		ids := uniqueObjectIDs(target.PhotoIDs)
+		if len(ids) > maxBulkPhotos {
+			return nil, fmt.Errorf("%w: at most %d", ErrBulkTooManyPhotos, maxBulkPhotos)
+		}
+		return ids, nil
+	}
+
+	query, err := normalizeSmartQuery(target.Filter)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := smartQueryFilter(userID, query)
+	if err != nil {
+		return nil, err
+	}
+	photos, total, err := s.photoRepo.Find(ctx, filter, 1, maxBulkPhotos)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve filter: %w", err)
+	}
+	if total > maxBulkPhotos {
+		return nil, fmt.Errorf("%w: filter matches %d photos (max %d)", ErrBulkTooManyPhotos, total, maxBulkPhotos)
+	}
+	ids := make([]primitive.ObjectID, 0, len(photos))
+	for _, photo := range photos {
+		ids = append(ids, photo.ID)
+	}
+	return ids, nil
+}
+
+// updateFields 一次性写入标签与描述的修改，返回生效的操作；无需修改时不写库
+func (s *BulkService) updateFields(ctx context.Context, photo *models.Photo, ops BulkOperations) ([]string, error) {
+	update := bson.M{}
+	if len(ops.AddTags) > 0 || len(ops.RemoveTags) > 0 {
+		kept := make([]models.Tag, 0, len(photo.Tags))
+		for _, tag := range photo.Tags {
+			if !containsTagName(ops.RemoveTags, tag.Name) {
+				kept = append(kept, tag)
+			}
+		}
+		additions := make([]models.Tag, 0, len(ops.AddTags))
+		for _, name := range ops.AddTags {
+			additions = append(additions, models.Tag{Name: name, Source: "USER"})
+		}
+		update["tags"] = mergeTags(kept, additions)
+	}
+	if ops.Description != nil {
+		update["description"] = *ops.Description
+	}
+	if len(update) == 0 {
+		return nil, nil
+	}
+	if err := s.photoRepo.Update(ctx, photo.ID, update); err != nil {
+		return nil, fmt.Errorf("failed to update photo: %w", err)
+	}
+
+	var applied []string
+	if len(ops.AddTags) > 0 {
+		applied = append(applied, BulkOpAddTags)
+	}
+	if len(ops.RemoveTags) > 0 {
+		applied = append(applied, BulkOpRemoveTags)
+	}
+	if ops.Description != nil {
+		applied = append(applied, BulkOpSetDescription)
+	}
+	return applied, nil
+}
+
+// fail 标记失败；已有操作生效时为 partial
+func (r *BulkResult) fail(err error) {
+	r.Status = BulkStatusFailed
+	if len(r.Applied) > 0 {
+		r.Status = BulkStatusPartial
+	}
+	r.Error = err.Error()
+}
+
+// cleanTagNames 去除空白与重复（不区分大小写）的标签名
+func cleanTagNames(names []string) []string {
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name != "" && !containsTagName(out, name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func containsTagName(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
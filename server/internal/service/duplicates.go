@@ -0,0 +1,185 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"photoms/internal/models"
+	"photoms/pkg/utils"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// DefaultDuplicateThreshold 默认汉明距离阈值（64 位 dHash）
+	DefaultDuplicateThreshold = 6
+	MaxDuplicateThreshold     = 16
+)
+
+var ErrInvalidDuplicateThreshold = errors.New("invalid duplicate threshold")
+
+// DuplicateGroup 一组近似重复的图片（按上传时间升序，第一张通常是保留的原件）
+type DuplicateGroup struct {
+	Photos      []*models.Photo `json:"photos"`
+	MaxDistance int             `json:"maxDistance"`
+}
+
+// FindDuplicates 按感知哈希的汉明距离对用户的图片分组，只返回包含两张及以上图片的组，并返回总组数。
+// 距离不超过 threshold 的图片视为相连，组内按连通关系合并（A~B、B~C 时 A/B/C 同组）。
+// 组按大小降序、其次按最早上传的图片排序后分页，只加载当前页各组的图片。
+func (s *PhotoService) FindDuplicates(ctx context.Context, userID primitive.ObjectID, threshold int, page, limit int64) ([]DuplicateGroup, int64, error) {
+	if threshold < 0 || threshold > MaxDuplicateThreshold {
+		return nil, 0, fmt.Errorf("%w: must be between 0 and %d", ErrInvalidDuplicateThreshold, MaxDuplicateThreshold)
+	}
+
+	candidates, err := s.repo.FindPerceptualHashes(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(candidates))
+	hashes := make([]uint64, 0, len(candidates))
+	for _, p := range candidates {
+		if h, ok := utils.ParsePerceptualHash(p.PHash); ok {
+			ids = append(ids, p.ID)
+			hashes = append(hashes, h)
+		}
+	}
+
+	groupIdx := duplicateClusters(hashes, threshold)
+	// ObjectID 以创建时间开头，组内最小的 ID 即最早上传的图片
+	for _, idx := range groupIdx {
+		sort.Slice(idx, func(a, b int) bool { return objectIDLess(ids[idx[a]], ids[idx[b]]) })
+	}
+	sort.Slice(groupIdx, func(i, j int) bool {
+		if len(groupIdx[i]) != len(groupIdx[j]) {
+			return len(groupIdx[i]) > len(groupIdx[j])
+		}
+		return objectIDLess(ids[groupIdx[i][0]], ids[groupIdx[j][0]])
+	})
+
+	total := int64(len(groupIdx))
+	from := min((page-1)*limit, total)
+	groupIdx = groupIdx[from:min(from+limit, total)]
+	if len(groupIdx) == 0 {
+		return []DuplicateGroup{}, total, nil
+	}
+
+	var groupIDs []primitive.ObjectID
+	for _, idx := range groupIdx {
+		for _, i := range idx {
+			groupIDs = append(groupIDs, ids[i])
+		}
+	}
+	photos, err := s.repo.FindByIDs(ctx, groupIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[primitive.ObjectID]*models.Photo, len(photos))
+	for _, p := range photos {
+		byID[p.ID] = p
+	}
+
+	groups := make([]DuplicateGroup, 0, len(groupIdx))
+	for _, idx := range groupIdx {
+		group := DuplicateGroup{}
+		for a := 0; a < len(idx); a++ {
+			for b := a + 1; b < len(idx); b++ {
+				if d := utils.HammingDistance(hashes[idx[a]], hashes[idx[b]]); d > group.MaxDistance {
+					group.MaxDistance = d
+				}
+			}
+			if p, ok := byID[ids[idx[a]]]; ok {
+				group.Photos = append(group.Photos, p)
+			}
+		}
+		if len(group.Photos) < 2 {
+			continue
+		}
+		sort.Slice(group.Photos, func(i, j int) bool {
+			return group.Photos[i].CreatedAt < group.Photos[j].CreatedAt
+		})
+		groups = append(groups, group)
+	}
+	return groups, total, nil
+}
+
+// duplicateClusters 返回汉明距离在 threshold 内连通的哈希下标分组（只含两个及以上成员的组）。
+// 哈希分为 threshold+1 段，只比较至少一段相同的哈希（见 utils.HashBands），无需两两比较全部哈希
+func duplicateClusters(hashes []uint64, threshold int) [][]int {
+	// 并查集合并距离在阈值内的图片
+	parent := make([]int, len(hashes))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	type bandKey struct {
+		band  int
+		value uint64
+	}
+	buckets := make(map[bandKey][]int)
+	for i, h := range hashes {
+		for band, value := range utils.HashBands(h, threshold+1) {
+			key := bandKey{band: band, value: value}
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+	for _, bucket := range buckets {
+		for a := 0; a < len(bucket); a++ {
+			for b := a + 1; b < len(bucket); b++ {
+				i, j := bucket[a], bucket[b]
+				// 已在同一组的无需再比较（同一对哈希可能在多个段中相遇）
+				if ri, rj := find(i), find(j); ri != rj && utils.HammingDistance(hashes[i], hashes[j]) <= threshold {
+					parent[rj] = ri
+				}
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range hashes {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+	groups := make([][]int, 0)
+	for _, idx := range members {
+		if len(idx) >= 2 {
+			groups = append(groups, idx)
+		}
+	}
+	return groups
+}
+
+func objectIDLess(a, b primitive.ObjectID) bool {
+	return bytes.Compare(a[:], b[:]) < 0
+}
+
+// BackfillPerceptualHash 为缺少感知哈希的历史图片计算 dHash，同步更新共用该文件的所有记录
+func (s *PhotoService) BackfillPerceptualHash(ctx context.Context, photo *models.Photo) error {
+	if photo == nil || photo.FileName == "" {
+		return nil
+	}
+
+	localPath, cleanup, err := s.fetchToTemp(ctx, photo.FileName)
+	if err != nil {
+		return fmt.Errorf("failed to load original image: %w", err)
+	}
+	defer cleanup()
+
+	img, err := utils.OpenImage(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+	_, err = s.repo.UpdateByFileName(ctx, photo.FileName, bson.M{"phash": utils.PerceptualHash(img)})
+	return err
+}
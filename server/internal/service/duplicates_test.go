@@ -0,0 +1,84 @@
+package service
+
+import (
+	"math/rand"
+	"photoms/pkg/utils"
+	"sort"
+	"testing"
+)
+
+// bruteForceClusters 两两比较得到的连通分组，作为 duplicateClusters 的对照
+func bruteForceClusters(hashes []uint64, threshold int) [][]int {
+	parent := make([]int, len(hashes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range hashes {
+		for j := i + 1; j < len(hashes); j++ {
+			if utils.HammingDistance(hashes[i], hashes[j]) <= threshold {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+	members := make(map[int][]int)
+	for i := range hashes {
+		members[find(i)] = append(members[find(i)], i)
+	}
+	var groups [][]int
+	for _, idx := range members {
+		if len(idx) >= 2 {
+			groups = append(groups, idx)
+		}
+	}
+	return groups
+}
+
+func normalizeClusters(groups [][]int) [][]int {
+	for _, g := range groups {
+		sort.Ints(g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+	return groups
+}
+
+func TestDuplicateClusters(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	// 若干原图，每张派生出几张翻转少量位的近似图
+	var hashes []uint64
+	for i := 0; i < 60; i++ {
+		base := rng.Uint64()
+		hashes = append(hashes, base)
+		for j := rng.Intn(4); j > 0; j-- {
+			h := base
+			for _, bit := range rng.Perm(64)[:rng.Intn(12)] {
+				h ^= 1 << bit
+			}
+			hashes = append(hashes, h)
+		}
+	}
+
+	for _, threshold := range []int{0, 1, 6, 10, 16} {
+		got := normalizeClusters(duplicateClusters(hashes, threshold))
+		want := normalizeClusters(bruteForceClusters(hashes, threshold))
+		if len(got) != len(want) {
+			t.Fatalf("threshold %d: got %d groups, want %d", threshold, len(got), len(want))
+		}
+		for i := range got {
+			if len(got[i]) != len(want[i]) {
+				t.Fatalf("threshold %d: group %d = %v, want %v", threshold, i, got[i], want[i])
+			}
+			for k := range got[i] {
+				if got[i][k] != want[i][k] {
+					t.Fatalf("threshold %d: group %d = %v, want %v", threshold, i, got[i], want[i])
+				}
+			}
+		}
+	}
+}
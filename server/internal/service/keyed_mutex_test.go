@@ -0,0 +1,35 @@
+package service
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	m := newKeyedMutex()
+
+	// map 只读，各 key 的计数只在持有该 key 的锁时修改；未串行时 -race 会报告数据竞争
+	counters := map[string]*int{"a": new(int), "b": new(int)}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		for key, counter := range counters {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				unlock := m.lock(key)
+				defer unlock()
+				*counter++
+			}()
+		}
+	}
+	wg.Wait()
+
+	for _, key := range []string{"a", "b"} {
+		if *counters[key] != 50 {
+			t.Errorf("counter %q = %d, want 50", key, *counters[key])
+		}
+	}
+	if len(m.locks) != 0 {
+		t.Errorf("locks not released: %d left", len(m.locks))
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"photoms/internal/models"
+	"photoms/pkg/storage"
+	"photoms/pkg/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrUnknownVariant = errors.New("unknown photo variant")
+
+// MediaObject 从存储后端打开的媒体文件，调用方负责关闭 Reader
+type MediaObject struct {
+	Reader io.ReadCloser
+	Info   *storage.ObjectInfo
+}
+
+// PresentPhoto 返回用于 API 响应的副本：Path/ThumbPath/各规格缩略图替换为带签名、会过期的 /media 地址，
+// 使 <img> 等无法携带 Authorization 头的场景也能访问。数据库中保存的值不受影响。
+// renditions 非空时仅返回指定名称的缩略图规格。
+func (s *PhotoService) PresentPhoto(photo *models.Photo, renditions ...string) *models.Photo {
+	if photo == nil {
+		return nil
+	}
+	out := *photo
+	out.Path = s.SignMediaPath(photo.Path)
+	out.ThumbPath = s.SignMediaPath(photo.ThumbPath)
+
+	selected := selectRenditions(photo.Renditions, renditions)
+	if selected != nil {
+		out.Renditions = make(map[string]models.Rendition, len(selected))
+		for name, r := range selected {
+			r.Path = s.SignMediaPath(r.Path)
+			out.Renditions[name] = r
+		}
+	} else {
+		out.Renditions = nil
+	}
+	return &out
+}
+
+func (s *PhotoService) PresentPhotos(photos []*models.Photo, renditions ...string) []*models.Photo {
+	out := make([]*models.Photo, 0, len(photos))
+	for _, photo := range photos {
+		if photo == nil {
+			continue
+		}
+		out = append(out, s.PresentPhoto(photo, renditions...))
+	}
+	return out
+}
+
+// SignMediaPath 将存储路径转换为带签名的媒体访问路径
+func (s *PhotoService) SignMediaPath(webPath string) string {
+	key := utils.MediaKeyFromPath(webPath)
+	if key == "" {
+		return ""
+	}
+	ttl := time.Duration(s.config.MediaURLTTLMinutes) * time.Minute
+	return utils.SignMediaPath(key, s.config.MediaSigningKey, utils.MediaExpiry(time.Now(), ttl))
+}
+
+// OpenSignedMedia 校验签名后打开媒体文件（无需登录）
+func (s *PhotoService) OpenSignedMedia(ctx context.Context, key, exp, sig string) (*MediaObject, error) {
+	if err := utils.VerifyMediaSignature(key, exp, sig, s.config.MediaSigningKey); err != nil {
+		return nil, err
+	}
+	return s.openMedia(ctx, key)
+}
+
+// OpenPhotoFile 打开当前用户图片的原图、缩略图或指定名称的缩略图规格（验证所有权）
+func (s *PhotoService) OpenPhotoFile(ctx context.Context, photoID, userID primitive.ObjectID, variant string) (*MediaObject, error) {
+	photo, err := s.GetPhotoByID(ctx, photoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.openPhotoVariant(ctx, photo, variant)
+}
+
+// isPreviewVariant 判断规格是否可用于预览（不允许下载的分享只提供预览）：thumb 与尺寸不超过默认展示规格
+// （ThumbnailDefaultRendition）的缩略图；原图以及存储 key 与原图相同的规格（如缩略图生成失败时的 thumb）都不是预览
+func (s *PhotoService) isPreviewVariant(photo *models.Photo, variant string) bool {
+	var webPath string
+	switch variant {
+	case "", "original":
+		return false
+	case "thumb":
+		webPath = photo.ThumbPath
+	default:
+		r, ok := photo.Renditions[variant]
+		limit, hasLimit := photo.Renditions[s.config.ThumbnailDefaultRendition]
+		if !ok || !hasLimit || r.Width > limit.Width || r.Height > limit.Height {
+			return false
+		}
+		webPath = r.Path
+	}
+	key := utils.MediaKeyFromPath(webPath)
+	return key != "" && key != photo.FileName && key != utils.MediaKeyFromPath(photo.Path)
+}
+
+func (s *PhotoService) openPhotoVariant(ctx context.Context, photo *models.Photo, variant string) (*MediaObject, error) {
+	var webPath string
+	switch variant {
+	case "", "original":
+		webPath = photo.Path
+	case "thumb":
+		webPath = photo.ThumbPath
+	default:
+		r, ok := photo.Renditions[variant]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownVariant, variant)
+		}
+		webPath = r.Path
+	}
+	return s.openMedia(ctx, utils.MediaKeyFromPath(webPath))
+}
+
+func (s *PhotoService) openMedia(ctx context.Context, key string) (*MediaObject, error) {
+	info, err := s.storage.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := s.storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &MediaObject{Reader: rc, Info: info}, nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"photoms/internal/models"
+	"photoms/pkg/config"
+	"testing"
+)
+
+func TestIsPreviewVariant(t *testing.T) {
+	s := &PhotoService{config: &config.Config{ThumbnailDefaultRendition: "w400"}}
+	photo := &models.Photo{
+		FileName:  "abc.jpg",
+		Path:      "/uploads/abc.jpg",
+		ThumbPath: "/uploads/thumbs/abc_w400.jpg",
+		Renditions: map[string]models.Rendition{
+			"sq200": {Path: "/uploads/thumbs/abc_sq200.jpg", Width: 200, Height: 200},
+			"w400":  {Path: "/uploads/thumbs/abc_w400.jpg", Width: 400, Height: 300},
+			"w2048": {Path: "/uploads/thumbs/abc_w2048.jpg", Width: 2048, Height: 1536},
+			// 原图小于规格时缩略图可能直接指向原图
+			"tiny": {Path: "/uploads/abc.jpg", Width: 100, Height: 80},
+		},
+	}
+	tests := []struct {
+		variant string
+		want    bool
+	}{
+		{"", false},
+		{"original", false},
+		{"thumb", true},
+		{"sq200", true},
+		{"w400", true},
+		{"w2048", false},
+		{"tiny", false},
+		{"missing", false},
+	}
+	for _, tt := range tests {
+		if got := s.isPreviewVariant(photo, tt.variant); got != tt.want {
+			t.Errorf("isPreviewVariant(%q) = %v, want %v", tt.variant, got, tt.want)
+		}
+	}
+
+	// 缩略图就是原图时不算预览
+	original := *photo
+	original.ThumbPath = original.Path
+	if s.isPreviewVariant(&original, "thumb") {
+		t.Error("thumb pointing at the original should not be a preview")
+	}
+
+	// 默认规格不存在时没有可比较的上限，所有规格都不算预览
+	noDefault := *photo
+	noDefault.Renditions = map[string]models.Rendition{"sq200": photo.Renditions["sq200"]}
+	if s.isPreviewVariant(&noDefault, "sq200") {
+		t.Error("renditions should not be previews without the default rendition")
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"photoms/internal/models"
+	"photoms/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// photoAccessRole 返回非所有者通过共享相册访问图片的最高角色，无权访问时返回空。
+// 手动相册按图片列表判断；智能相册只可能包含其所有者的图片，按查询条件判断。
+func (s *PhotoService) photoAccessRole(ctx context.Context, photo *models.Photo, userID primitive.ObjectID) (string, error) {
+	if photo.UserID == userID {
+		return models.AlbumRoleOwner, nil
+	}
+
+	best := ""
+	albums, err := s.albumRepo.FindAccessibleWithPhoto(ctx, photo.ID, userID)
+	if err != nil {
+		return "", err
+	}
+	for _, album := range albums {
+		if role := albumRole(album, userID); albumRoleRank[role] > albumRoleRank[best] {
+			best = role
+		}
+	}
+
+	smartAlbums, err := s.albumRepo.FindSmartSharedWith(ctx, photo.UserID, userID)
+	if err != nil {
+		return "", err
+	}
+	for _, album := range smartAlbums {
+		role := albumRole(album, userID)
+		if albumRoleRank[role] <= albumRoleRank[best] {
+			continue
+		}
+		smart := smartAlbumFilter(album)
+		_, total, err := s.repo.Find(ctx, repository.PhotoFilter{
+			IDs:    []primitive.ObjectID{photo.ID},
+			Within: &smart,
+		}, 1, 1)
+		if err != nil {
+			return "", err
+		}
+		if total > 0 {
+			best = role
+		}
+	}
+	return best, nil
+}
+
+// checkMemberTagging 非所有者修改图片时，只允许 contributor 及以上角色的成员修改标签
+func (s *PhotoService) checkMemberTagging(ctx context.Context, photo *models.Photo, userID primitive.ObjectID, updates map[string]interface{}) error {
+	role, err := s.photoAccessRole(ctx, photo, userID)
+	if err != nil {
+		return err
+	}
+	if !roleAtLeast(role, models.AlbumRoleContributor) {
+		return fmt.Errorf("unauthorized: photo belongs to another user")
+	}
+	for key := range updates {
+		if key != "tags" {
+			return fmt.Errorf("unauthorized: album members can only update tags")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"photoms/internal/models"
+	"photoms/internal/repository"
+	"photoms/pkg/geocode"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// clusterCellPixels 地图聚合时每个网格的边长（像素）
+const clusterCellPixels = 60
+
+// maxClusters 单次聚合最多返回的网格数
+const maxClusters = 1000
+
+// NearbyPhotos 查询当前用户在 filter.Near 范围内的图片，按距离由近到远排序
+func (s *PhotoService) NearbyPhotos(ctx context.Context, userID primitive.ObjectID, filter repository.PhotoFilter, page, limit int64) ([]*models.Photo, int64, error) {
+	filter.UserID = &userID
+	filter.IDs = nil
+	photos, total, err := s.repo.FindNear(ctx, filter, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	highlightPhotos(photos, filter.Q)
+	return photos, total, nil
+}
+
+// PhotoClusters 将当前用户满足条件的带位置图片按地图缩放级别聚合，并附上每个网格的封面图片
+func (s *PhotoService) PhotoClusters(ctx context.Context, userID primitive.ObjectID, filter repository.PhotoFilter, zoom int) ([]*repository.GeoCluster, error) {
+	filter.UserID = &userID
+	filter.IDs = nil
+	clusters, err := s.repo.Clusters(ctx, filter, zoom, clusterCellPixels, maxClusters)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(clusters))
+	for _, cluster := range clusters {
+		ids = append(ids, cluster.PhotoID)
+	}
+	photos, err := s.repo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]*models.Photo, len(photos))
+	for _, photo := range photos {
+		byID[photo.ID] = photo
+	}
+	for _, cluster := range clusters {
+		cluster.Photo = byID[cluster.PhotoID]
+	}
+	return clusters, nil
+}
+
+// placeOf 由 EXIF 中的 GPS 坐标离线解析拍摄地点，没有 GPS 或附近没有收录的城市时返回 nil
+func placeOf(exifInfo *models.ExifInfo) *models.Place {
+	if exifInfo == nil {
+		return nil
+	}
+	return geocode.Lookup(exifInfo.GPS)
+}
+
+// BackfillPlace 为图片（重新）解析拍摄地点，以新地点的名称（中英文）替换旧地点生成的标签，返回是否更新
+func (s *PhotoService) BackfillPlace(ctx context.Context, photo *models.Photo) (bool, error) {
+	place := placeOf(photo.Exif)
+	if place == nil && photo.Place == nil {
+		return false, nil
+	}
+
+	var tags []models.Tag
+	for _, name := range geocode.Names(place) {
+		tags = append(tags, models.Tag{Name: name, Source: models.TagSourceAI})
+	}
+	// 重新解析时先去掉旧地点生成的标签，避免同时带有两个城市
+	existing := photo.Tags
+	if photo.Place != nil {
+		existing = withoutPlaceTags(existing, photo.Place)
+	}
+	err := s.repo.Update(ctx, photo.ID, bson.M{
+		"place": place,
+		"tags":  mergeTags(existing, tags),
+	})
+	return err == nil, err
+}
+
+// withoutPlaceTags 去掉由拍摄地点自动生成的标签（用户手动添加的同名标签保留）
+func withoutPlaceTags(tags []models.Tag, place *models.Place) []models.Tag {
+	names := make(map[string]struct{})
+	for _, name := range geocode.Names(place) {
+		names[strings.ToLower(name)] = struct{}{}
+	}
+	out := make([]models.Tag, 0, len(tags))
+	for _, tag := range tags {
+		if _, ok := names[strings.ToLower(tag.Name)]; ok && tag.Source == models.TagSourceAI {
+			continue
+		}
+		out = append(out, tag)
+	}
+	return out
+}
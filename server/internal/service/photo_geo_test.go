@@ -0,0 +1,44 @@
+package service
+
+import (
+	"photoms/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestWithoutPlaceTags(t *testing.T) {
+	place := &models.Place{
+		CountryCode: "CN", Country: "中国", CountryEn: "China",
+		Province: "浙江", ProvinceEn: "Zhejiang",
+		City: "杭州", CityEn: "Hangzhou",
+	}
+	tags := []models.Tag{
+		{Name: "杭州", Source: models.TagSourceAI},
+		{Name: "hangzhou", Source: models.TagSourceAI},
+		{Name: "西湖", Source: models.TagSourceAI},
+		{Name: "China", Source: models.TagSourceUser},
+		{Name: "浙江", Source: models.TagSourceAI},
+		{Name: "风景", Source: models.TagSourceUser},
+	}
+
+	tests := []struct {
+		name  string
+		place *models.Place
+		want  []string
+	}{
+		// 地点标签只移除 AI 来源的，用户手动添加的同名标签保留
+		{"removes ai place tags", place, []string{"西湖", "China", "风景"}},
+		{"nil place keeps all", nil, []string{"杭州", "hangzhou", "西湖", "China", "浙江", "风景"}},
+		{"region only", &models.Place{CountryCode: "CN", Country: "中国", CountryEn: "China", Province: "浙江", ProvinceEn: "Zhejiang"},
+			[]string{"杭州", "hangzhou", "西湖", "China", "风景"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, tag := range withoutPlaceTags(tags, tt.place) {
+			got = append(got, tag.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -7,138 +7,337 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"mime/multipart"
 	"os"
+	"path"
 	"path/filepath"
 	"photoms/internal/models"
 	"photoms/internal/repository"
 	"photoms/pkg/ai"
 	"photoms/pkg/config"
+	"photoms/pkg/geocode"
+	"photoms/pkg/storage"
+	"photoms/pkg/textsearch"
 	"photoms/pkg/utils"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/disintegration/imaging"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrInvalidPhotoUpdate 更新图片时字段取值无效（如星级超出 0-5）
+var ErrInvalidPhotoUpdate = errors.New("invalid photo update")
+
 type PhotoService struct {
-	repo   *repository.PhotoRepository
-	config *config.Config
-	tagger ai.ImageTagger
+	repo      *repository.PhotoRepository
+	albumRepo *repository.AlbumRepository
+	storage   storage.Backend
+	config    *config.Config
+	tagger    ai.ImageTagger
+	hashLocks *keyedMutex
 }
 
-func NewPhotoService(repo *repository.PhotoRepository, cfg *config.Config) *PhotoService {
+func NewPhotoService(repo *repository.PhotoRepository, albumRepo *repository.AlbumRepository, backend storage.Backend, cfg *config.Config) *PhotoService {
 	tagger, err := ai.NewImageTagger(cfg)
 	if err != nil && !errors.Is(err, ai.ErrDisabled) {
 		fmt.Printf("Warning: AI tagger is not available: %v\n", err)
 	}
-	return &PhotoService{repo: repo, config: cfg, tagger: tagger}
+	return &PhotoService{repo: repo, albumRepo: albumRepo, storage: backend, config: cfg, tagger: tagger, hashLocks: newKeyedMutex()}
 }
 
 func (s *PhotoService) UploadPhoto(ctx context.Context, userID primitive.ObjectID, file *multipart.FileHeader) (*models.Photo, error) {
+	photo, _, err := s.uploadFile(ctx, userID, file)
+	return photo, err
+}
+
+// UploadResult 批量上传中单个文件的处理结果
+type UploadResult struct {
+	FileName string        `json:"fileName"`
+	Status   string        `json:"status"` // "created" / "deduplicated" / "rejected" / "failed"
+	Photo    *models.Photo `json:"photo,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+const (
+	UploadStatusCreated      = "created"
+	UploadStatusDeduplicated = "deduplicated"
+	UploadStatusRejected     = "rejected"
+	UploadStatusFailed       = "failed"
+)
+
+// UploadPhotos 批量上传：以有限并发逐个走 UploadPhoto 的处理流程，单个文件失败不影响其他文件。
+// 返回结果与 files 顺序一一对应。
+func (s *PhotoService) UploadPhotos(ctx context.Context, userID primitive.ObjectID, files []*multipart.FileHeader) []UploadResult {
+	results := make([]UploadResult, len(files))
+
+	concurrency := s.config.BatchUploadConcurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, file := range files {
+		wg.Add(1)
+		go func(i int, file *multipart.FileHeader) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := UploadResult{FileName: file.Filename}
+			if err := ctx.Err(); err != nil {
+				result.Status = UploadStatusFailed
+				result.Error = err.Error()
+				results[i] = result
+				return
+			}
+
+			photo, deduplicated, err := s.uploadFile(ctx, userID, file)
+			switch {
+			case err != nil && IsUploadRejection(err):
+				result.Status = UploadStatusRejected
+				result.Error = err.Error()
+			case err != nil:
+				result.Status = UploadStatusFailed
+				result.Error = err.Error()
+			case deduplicated:
+				result.Status = UploadStatusDeduplicated
+				result.Photo = photo
+			default:
+				result.Status = UploadStatusCreated
+				result.Photo = photo
+			}
+			results[i] = result
+		}(i, file)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// MaxBatchFiles 单次批量上传允许的最大文件数
+func (s *PhotoService) MaxBatchFiles() int {
+	return s.config.BatchUploadMaxFiles
+}
+
+func (s *PhotoService) uploadFile(ctx context.Context, userID primitive.ObjectID, file *multipart.FileHeader) (*models.Photo, bool, error) {
+	// 按声明大小快速拒绝，避免读取超大文件
+	if err := s.CheckUploadSize(file.Size); err != nil {
+		return nil, false, err
+	}
+
 	src, err := file.Open()
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	defer src.Close()
 
-	// 1. 计算文件 Hash 用于秒传
+	return s.importPhoto(ctx, userID, primitive.NilObjectID, file.Filename, src)
+}
+
+// ImportPhoto 将图片数据写入图片库：校验内容、计算 Hash（秒传）、提取 EXIF、生成缩略图并保存到存储后端。
+// 普通上传与断点续传完成后的合并文件共用这一流程；MIME 类型由服务端根据文件内容识别。
+// photoID 非零时以该 ID 创建记录（已存在时返回重复键错误），供断点续传的完成操作重试时保持幂等。
+func (s *PhotoService) ImportPhoto(ctx context.Context, userID, photoID primitive.ObjectID, fileName string, src io.Reader) (*models.Photo, error) {
+	photo, _, err := s.importPhoto(ctx, userID, photoID, fileName, src)
+	return photo, err
+}
+
+// importPhoto 同 ImportPhoto，额外返回是否命中秒传（复用已有文件）
+func (s *PhotoService) importPhoto(ctx context.Context, userID, photoID primitive.ObjectID, fileName string, src io.Reader) (*models.Photo, bool, error) {
+	// 图片处理（EXIF/缩略图）需要本地文件，先落到临时目录，处理完成后再写入存储后端
+	workDir, err := os.MkdirTemp("", "photoms-upload-*")
+	if err != nil {
+		return nil, false, err
+	}
+	defer os.RemoveAll(workDir)
+
+	localPath := filepath.Join(workDir, "original")
+	dst, err := os.Create(localPath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// 1. 写入临时文件的同时计算 Hash 用于秒传（超过大小上限立即中止）
+	reader := src
+	maxBytes := s.MaxUploadBytes()
+	if maxBytes > 0 {
+		reader = io.LimitReader(src, maxBytes+1)
+	}
 	hash := sha256.New()
-	if _, err := io.Copy(hash, src); err != nil {
-		return nil, err
+	size, err := io.Copy(io.MultiWriter(dst, hash), reader)
+	if err != nil {
+		dst.Close()
+		return nil, false, err
+	}
+	if err := dst.Close(); err != nil {
+		return nil, false, err
+	}
+	if err := s.CheckUploadSize(size); err != nil {
+		return nil, false, err
+	}
+
+	// 2. 根据文件头识别真实类型，校验白名单与像素上限（在 imaging 解码之前）
+	mimeType, ext, err := s.validateImageFile(localPath)
+	if err != nil {
+		return nil, false, err
 	}
 	fileHash := hex.EncodeToString(hash.Sum(nil))
 
+	// 同一 Hash 的导入串行执行：同一批次或并发上传的相同文件，后完成查重的一方会命中先保存的记录
+	unlock := s.hashLocks.lock(fileHash)
+	defer unlock()
+
 	// 检查是否已存在相同 Hash 的图片
 	existing, _ := s.repo.FindByHash(ctx, fileHash)
 	if existing != nil {
 		// 秒传逻辑：复用文件/EXIF/缩略图，但不复用用户元数据（标题/描述/标签）
+		place := placeOf(existing.Exif)
 		newPhoto := &models.Photo{
-			UserID:    userID,
-			Title:     file.Filename,
-			FileName:  existing.FileName,
-			Path:      existing.Path,
-			ThumbPath: existing.ThumbPath,
-			Hash:      existing.Hash,
-			Size:      existing.Size,
-			MimeType:  existing.MimeType,
-			Exif:      existing.Exif,
-			Tags:      buildAutoTags(existing.Exif, filepath.Ext(existing.FileName), existing.MimeType),
+			ID:         photoID,
+			UserID:     userID,
+			Title:      fileName,
+			FileName:   existing.FileName,
+			Path:       existing.Path,
+			ThumbPath:  existing.ThumbPath,
+			Hash:       existing.Hash,
+			PHash:      existing.PHash,
+			Size:       existing.Size,
+			MimeType:   existing.MimeType,
+			Exif:       existing.Exif,
+			Place:      place,
+			Tags:       buildAutoTags(existing.Exif, place, filepath.Ext(existing.FileName), existing.MimeType),
+			Renditions: existing.Renditions,
+			TakenAt:    utils.CaptureTime(existing.Exif, time.Now()),
 		}
 
 		if err := s.repo.Create(ctx, newPhoto); err != nil {
-			return nil, err
+			return nil, false, err
 		}
 		s.maybeGenerateAITagsAsync(newPhoto.ID, userID)
-		return newPhoto, nil
+		return newPhoto, true, nil
 	}
 
-	// 2. 保存新文件
-	src.Seek(0, 0) // 重置读取位置
-	ext := filepath.Ext(file.Filename)
+	// 3. 保存新文件（扩展名取自识别出的类型，而非客户端文件名）
 	newFileName := fmt.Sprintf("%d_%s%s", time.Now().UnixNano(), fileHash[:8], ext)
-	uploadPath := filepath.Join(s.config.UploadDir, newFileName)
 
-	// 确保目录存在
-	os.MkdirAll(s.config.UploadDir, os.ModePerm)
+	// 提取EXIF信息
+	exifInfo, _ := utils.ExtractExif(localPath)
 
-	dst, err := os.Create(uploadPath)
-	if err != nil {
-		return nil, err
+	if err := s.putFile(ctx, newFileName, localPath, mimeType); err != nil {
+		return nil, false, fmt.Errorf("failed to store file: %w", err)
 	}
-	defer dst.Close()
 
-	if _, err := io.Copy(dst, src); err != nil {
-		return nil, err
+	// 解码一次，用于生成各规格缩略图与感知哈希
+	var pHash string
+	img, err := utils.OpenImage(localPath)
+	if err != nil {
+		fmt.Printf("Warning: failed to decode image: %v\n", err)
+	} else {
+		pHash = utils.PerceptualHash(img)
 	}
 
-	// 提取EXIF信息
-	exifInfo, _ := utils.ExtractExif(uploadPath)
-
-	// 生成缩略图
-	thumbFileName := fmt.Sprintf("thumb_%s.jpg", strings.TrimSuffix(newFileName, ext))
-	thumbPath := filepath.Join(s.config.UploadDir, thumbFileName)
-	if err := utils.GenerateThumbnail(uploadPath, thumbPath, 400); err != nil {
-		fmt.Printf("Warning: failed to generate thumbnail: %v\n", err)
+	// 生成各规格缩略图
+	renditions, thumbFileName := s.storeRenditions(ctx, img, localPath, workDir, strings.TrimSuffix(newFileName, ext))
+	if thumbFileName == "" {
 		thumbFileName = newFileName // 失败时使用原图
 	}
 
 	// 构造数据库模型
-	mimeType := file.Header.Get("Content-Type")
-	autoTags := buildAutoTags(exifInfo, ext, mimeType)
+	place := placeOf(exifInfo)
+	autoTags := buildAutoTags(exifInfo, place, ext, mimeType)
 
 	photo := &models.Photo{
-		UserID:    userID,
-		Title:     file.Filename,
-		FileName:  newFileName,
-		Path:      "/uploads/" + newFileName, // 用于前端访问
-		ThumbPath: "/uploads/" + thumbFileName,
-		Hash:      fileHash,
-		Size:      file.Size,
-		MimeType:  mimeType,
-		Exif:      exifInfo,
-		Tags:      autoTags,
+		ID:         photoID,
+		UserID:     userID,
+		Title:      fileName,
+		FileName:   newFileName,
+		Path:       s.storage.URL(newFileName), // 用于前端访问
+		ThumbPath:  s.storage.URL(thumbFileName),
+		Hash:       fileHash,
+		PHash:      pHash,
+		Size:       size,
+		MimeType:   mimeType,
+		Exif:       exifInfo,
+		Place:      place,
+		Tags:       autoTags,
+		Renditions: renditions,
+		TakenAt:    utils.CaptureTime(exifInfo, time.Now()),
 	}
 
 	if err := s.repo.Create(ctx, photo); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	s.maybeGenerateAITagsAsync(photo.ID, userID)
-	return photo, nil
+	return photo, false, nil
 }
 
-func (s *PhotoService) ListPhotos(ctx context.Context, userID primitive.ObjectID, page, limit int64, q, tag string, startDate, endDate *time.Time) ([]*models.Photo, int64, error) {
-	return s.repo.FindByUserID(ctx, userID, page, limit, q, tag, startDate, endDate)
+// ListPhotos 分页查询当前用户的图片
+func (s *PhotoService) ListPhotos(ctx context.Context, userID primitive.ObjectID, filter repository.PhotoFilter, page, limit int64) ([]*models.Photo, int64, error) {
+	filter.UserID = &userID
+	filter.IDs = nil
+	photos, total, err := s.repo.Find(ctx, filter, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	highlightPhotos(photos, filter.Q)
+	return photos, total, nil
 }
 
-// GetPhotoByID 获取单张图片详情（验证用户所有权）
+// highlightPhotos 为关键词检索的结果填充高亮片段
+func highlightPhotos(photos []*models.Photo, q string) {
+	if q == "" {
+		return
+	}
+	for _, photo := range photos {
+		photo.Highlights = textsearch.Highlight(photo, q)
+	}
+}
+
+// PhotoFacets 统计当前用户满足过滤条件的图片的标签、相机、镜头与拍摄年月分布
+func (s *PhotoService) PhotoFacets(ctx context.Context, userID primitive.ObjectID, filter repository.PhotoFilter, limit int64) (*repository.PhotoFacets, error) {
+	filter.UserID = &userID
+	filter.IDs = nil
+	return s.repo.Facets(ctx, filter, limit)
+}
+
+// GetPhotoByID 获取单张图片详情（所有者，或通过共享相册可见该图片的成员）
 func (s *PhotoService) GetPhotoByID(ctx context.Context, photoID, userID primitive.ObjectID) (*models.Photo, error) {
 	photo, err := s.repo.FindByID(ctx, photoID)
 	if err != nil {
 		return nil, fmt.Errorf("photo not found: %w", err)
 	}
+	if photo.DeletedAt != nil {
+		return nil, fmt.Errorf("photo not found: %w", ErrPhotoInTrash)
+	}
+	if photo.UserID == userID {
+		return photo, nil
+	}
+
+	role, err := s.photoAccessRole(ctx, photo, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, fmt.Errorf("unauthorized: photo belongs to another user")
+	}
+	return photo, nil
+}
+
+// getOwnedPhoto 获取图片并验证用户所有权（删除、编辑等仅所有者可执行的操作）
+func (s *PhotoService) getOwnedPhoto(ctx context.Context, photoID, userID primitive.ObjectID) (*models.Photo, error) {
+	photo, err := s.repo.FindByID(ctx, photoID)
+	if err != nil {
+		return nil, fmt.Errorf("photo not found: %w", err)
+	}
+	if photo.DeletedAt != nil {
+		return nil, fmt.Errorf("photo not found: %w", ErrPhotoInTrash)
+	}
 
 	// 验证用户所有权
 	if photo.UserID != userID {
@@ -148,26 +347,43 @@ func (s *PhotoService) GetPhotoByID(ctx context.Context, photoID, userID primiti
 	return photo, nil
 }
 
-// UpdatePhoto 更新图片信息（仅允许更新标题、描述、标签）
+// UpdatePhoto 更新图片信息（仅允许更新标题、描述、标签、星级、收藏与颜色标签）。
+// 共享相册中 contributor 及以上角色的成员只能修改标签。
 func (s *PhotoService) UpdatePhoto(ctx context.Context, photoID, userID primitive.ObjectID, updates map[string]interface{}) (*models.Photo, error) {
-	// 先验证所有权
-	photo, err := s.GetPhotoByID(ctx, photoID, userID)
+	photo, err := s.repo.FindByID(ctx, photoID)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("photo not found: %w", err)
+	}
+	if photo.DeletedAt != nil {
+		return nil, fmt.Errorf("photo not found: %w", ErrPhotoInTrash)
+	}
+	if photo.UserID != userID {
+		if err := s.checkMemberTagging(ctx, photo, userID, updates); err != nil {
+			return nil, err
+		}
 	}
 
-	// 构造更新数据（只允许更新特定字段）
-	allowedFields := map[string]bool{
-		"title":       true,
-		"description": true,
-		"tags":        true,
+	// 构造更新数据（只允许更新特定字段，请求字段名 -> 数据库字段名）
+	allowedFields := map[string]string{
+		"title":       "title",
+		"description": "description",
+		"tags":        "tags",
+		"rating":      "rating",
+		"favorite":    "favorite",
+		"colorLabel":  "color_label",
 	}
 
 	updateData := make(map[string]interface{})
 	for key, value := range updates {
-		if allowedFields[key] {
-			updateData[key] = value
+		field, ok := allowedFields[key]
+		if !ok {
+			continue
 		}
+		value, err := normalizePhotoField(key, value)
+		if err != nil {
+			return nil, err
+		}
+		updateData[field] = value
 	}
 
 	// 如果没有有效的更新字段，直接返回原数据
@@ -184,6 +400,52 @@ func (s *PhotoService) UpdatePhoto(ctx context.Context, photoID, userID primitiv
 	return s.repo.FindByID(ctx, photoID)
 }
 
+// normalizePhotoField 校验星级、收藏与颜色标签的取值（JSON 数字解码为 float64）
+func normalizePhotoField(key string, value interface{}) (interface{}, error) {
+	switch key {
+	case "rating":
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) || n < 0 || n > 5 {
+			return nil, fmt.Errorf("%w: rating must be an integer between 0 and 5", ErrInvalidPhotoUpdate)
+		}
+		return int(n), nil
+	case "favorite":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%w: favorite must be a boolean", ErrInvalidPhotoUpdate)
+		}
+		return b, nil
+	case "colorLabel":
+		label, ok := value.(string)
+		if value == nil {
+			label, ok = "", true
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: colorLabel must be a string", ErrInvalidPhotoUpdate)
+		}
+		return NormalizeColorLabel(label)
+	default:
+		return value, nil
+	}
+}
+
+// NormalizeColorLabel 校验颜色标签（不区分大小写），空字符串表示清除标签
+func NormalizeColorLabel(label string) (string, error) {
+	label, ok := models.NormalizeColorLabel(label)
+	if !ok {
+		return "", fmt.Errorf("%w: unknown color label %q", ErrInvalidPhotoUpdate, label)
+	}
+	return label, nil
+}
+
+// NormalizeColorLabelFilter 校验用于过滤的颜色标签，额外接受 none（没有颜色标签）
+func NormalizeColorLabelFilter(label string) (string, error) {
+	if strings.EqualFold(strings.TrimSpace(label), repository.ColorLabelNone) {
+		return repository.ColorLabelNone, nil
+	}
+	return NormalizeColorLabel(label)
+}
+
 func (s *PhotoService) GenerateAITags(ctx context.Context, photoID, userID primitive.ObjectID) (*models.Photo, error) {
 	if s.config == nil || !s.config.AITaggingEnabled {
 		return nil, ai.ErrDisabled
@@ -192,15 +454,16 @@ func (s *PhotoService) GenerateAITags(ctx context.Context, photoID, userID primi
 		return nil, ai.ErrNotConfigured
 	}
 
-	photo, err := s.GetPhotoByID(ctx, photoID, userID)
+	photo, err := s.getOwnedPhoto(ctx, photoID, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	imagePath := resolvePhotoDiskPath(s.config.UploadDir, photo)
-	if imagePath == "" {
-		return nil, fmt.Errorf("failed to resolve photo file path")
+	imagePath, cleanup, err := s.fetchTaggingImage(ctx, photo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load photo file: %w", err)
 	}
+	defer cleanup()
 
 	aiTags, err := s.tagger.GenerateTags(ctx, imagePath)
 	if err != nil {
@@ -215,53 +478,28 @@ func (s *PhotoService) GenerateAITags(ctx context.Context, photoID, userID primi
 	return s.repo.FindByID(ctx, photoID)
 }
 
-// DeletePhoto 删除图片（包括文件和数据库记录）
-func (s *PhotoService) DeletePhoto(ctx context.Context, photoID, userID primitive.ObjectID) error {
-	// 先验证所有权
-	photo, err := s.GetPhotoByID(ctx, photoID, userID)
-	if err != nil {
-		return err
-	}
-
-	// 先删除数据库记录，避免文件已删除但数据库删除失败
-	if err := s.repo.Delete(ctx, photoID); err != nil {
-		return fmt.Errorf("failed to delete photo from database: %w", err)
-	}
-
-	// 若该文件被其他记录复用（秒传/重复上传），则跳过磁盘清理
-	if photo.FileName == "" {
-		return nil
-	}
-
-	remaining, err := s.repo.CountByFileName(ctx, photo.FileName)
-	if err != nil {
-		fmt.Printf("Warning: failed to count file references for %s: %v\n", photo.FileName, err)
-		return nil
-	}
-	if remaining > 0 {
-		return nil
+func (s *PhotoService) maybeGenerateAITagsAsync(photoID, userID primitive.ObjectID) {
+	if s.tagger == nil || s.config == nil || !s.config.AITaggingEnabled {
+		return
 	}
 
-	// 删除磁盘文件
-	filePath := filepath.Join(s.config.UploadDir, photo.FileName)
-	if err := os.Remove(filePath); err != nil {
-		fmt.Printf("Warning: failed to delete file %s: %v\n", filePath, err)
+	timeout := time.Duration(s.config.AITagTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 20 * time.Second
 	}
 
-	// 删除缩略图（如果与原图不同）
-	if photo.ThumbPath != "" && photo.ThumbPath != photo.Path {
-		thumbFileName := filepath.Base(photo.ThumbPath)
-		thumbPath := filepath.Join(s.config.UploadDir, thumbFileName)
-		if err := os.Remove(thumbPath); err != nil {
-			fmt.Printf("Warning: failed to delete thumbnail %s: %v\n", thumbPath, err)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if _, err := s.GenerateAITags(ctx, photoID, userID); err != nil {
+			fmt.Printf("Warning: AI tagging failed for photo %s: %v\n", photoID.Hex(), err)
 		}
-	}
-
-	return nil
+	}()
 }
 
-func (s *PhotoService) maybeGenerateAITagsAsync(photoID, userID primitive.ObjectID) {
-	if s.tagger == nil || s.config == nil || !s.config.AITaggingEnabled {
+// queueAITags 在后台逐张重新生成 AI 标签（批量操作使用，避免并发请求压垮模型服务）
+func (s *PhotoService) queueAITags(photoIDs []primitive.ObjectID, userID primitive.ObjectID) {
+	if len(photoIDs) == 0 || s.tagger == nil || s.config == nil || !s.config.AITaggingEnabled {
 		return
 	}
 
@@ -271,34 +509,99 @@ func (s *PhotoService) maybeGenerateAITagsAsync(photoID, userID primitive.Object
 	}
 
 	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
-		if _, err := s.GenerateAITags(ctx, photoID, userID); err != nil {
-			fmt.Printf("Warning: AI tagging failed for photo %s: %v\n", photoID.Hex(), err)
+		for _, photoID := range photoIDs {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			if _, err := s.GenerateAITags(ctx, photoID, userID); err != nil {
+				fmt.Printf("Warning: AI tagging failed for photo %s: %v\n", photoID.Hex(), err)
+			}
+			cancel()
 		}
 	}()
 }
 
-func resolvePhotoDiskPath(uploadDir string, photo *models.Photo) string {
+// fetchTaggingImage 将用于 AI 标注的图片下载到本地临时文件，返回路径与清理函数
+func (s *PhotoService) fetchTaggingImage(ctx context.Context, photo *models.Photo) (string, func(), error) {
 	if photo == nil {
-		return ""
+		return "", nil, fmt.Errorf("photo is nil")
 	}
 
 	// Prefer thumbnail to reduce payload size (fallback to original).
-	if p := joinUploadPath(uploadDir, photo.ThumbPath); p != "" {
-		if _, err := os.Stat(p); err == nil {
-			return p
+	if key := storageKey(photo.ThumbPath); key != "" && photo.ThumbPath != photo.Path {
+		if p, cleanup, err := s.fetchToTemp(ctx, key); err == nil {
+			return p, cleanup, nil
 		}
 	}
-	return joinUploadPath(uploadDir, photo.Path)
+
+	p, cleanup, err := s.fetchToTemp(ctx, storageKey(photo.Path))
+	if err != nil || photo.Exif == nil || photo.Exif.Orientation <= 1 {
+		return p, cleanup, err
+	}
+
+	// 原图带旋转方向时先转为正向，避免模型看到横躺的图片
+	defer cleanup()
+	img, err := utils.OpenImage(p)
+	if err != nil {
+		return "", nil, err
+	}
+	tmp, err := os.CreateTemp("", "photoms-*.jpg")
+	if err != nil {
+		return "", nil, err
+	}
+	name := tmp.Name()
+	tmp.Close()
+	if err := imaging.Save(img, name, imaging.JPEGQuality(90)); err != nil {
+		os.Remove(name)
+		return "", nil, err
+	}
+	return name, func() { os.Remove(name) }, nil
 }
 
-func joinUploadPath(uploadDir, webPath string) string {
-	base := filepath.Base(strings.TrimSpace(webPath))
-	if base == "" || base == "." || base == "/" {
-		return ""
+// fetchToTemp 将存储后端中的对象复制到本地临时文件（保留扩展名，便于识别图片格式）
+func (s *PhotoService) fetchToTemp(ctx context.Context, key string) (string, func(), error) {
+	if key == "" {
+		return "", nil, storage.ErrInvalidKey
+	}
+	rc, err := s.storage.Get(ctx, key)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rc.Close()
+
+	tmp, err := os.CreateTemp("", "photoms-*"+path.Ext(key))
+	if err != nil {
+		return "", nil, err
+	}
+	name := tmp.Name()
+	if _, err := io.Copy(tmp, rc); err != nil {
+		tmp.Close()
+		os.Remove(name)
+		return "", nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(name)
+		return "", nil, err
+	}
+	return name, func() { os.Remove(name) }, nil
+}
+
+// putFile 将本地文件写入存储后端
+func (s *PhotoService) putFile(ctx context.Context, key, localPath, contentType string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
 	}
-	return filepath.Join(uploadDir, base)
+	return s.storage.Put(ctx, key, f, info.Size(), contentType)
+}
+
+// storageKey 从 Path/ThumbPath（如 /uploads/xxx.jpg 或对象存储 URL）中取出存储 key
+func storageKey(webPath string) string {
+	return utils.MediaKeyFromPath(webPath)
 }
 
 func mergeTags(existing []models.Tag, additions []models.Tag) []models.Tag {
@@ -328,7 +631,7 @@ func mergeTags(existing []models.Tag, additions []models.Tag) []models.Tag {
 	return out
 }
 
-func buildAutoTags(exifInfo *models.ExifInfo, fileExt, mimeType string) []models.Tag {
+func buildAutoTags(exifInfo *models.ExifInfo, place *models.Place, fileExt, mimeType string) []models.Tag {
 	tags := make([]models.Tag, 0, 8)
 	seen := make(map[string]struct{}, 8)
 
@@ -375,6 +678,9 @@ func buildAutoTags(exifInfo *models.ExifInfo, fileExt, mimeType string) []models
 	if exifInfo.GPS != nil {
 		add("GPS")
 	}
+	for _, name := range geocode.Names(place) {
+		add(name)
+	}
 
 	return tags
 }
@@ -385,14 +691,26 @@ func (s *PhotoService) EditPhoto(ctx context.Context, photoID, userID primitive.
 	cropX, cropY, cropW, cropH int, brightness, contrast, saturation float64) (*models.Photo, error) {
 
 	// 获取原图
-	oldPhoto, err := s.GetPhotoByID(ctx, photoID, userID)
+	oldPhoto, err := s.getOwnedPhoto(ctx, photoID, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	// 下载原图到本地临时目录
+	oldBase := storageKey(oldPhoto.Path)
+	oldFilePath, cleanup, err := s.fetchToTemp(ctx, oldBase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load original image: %w", err)
+	}
+	defer cleanup()
+
+	workDir, err := os.MkdirTemp("", "photoms-edit-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
 	// 准备新文件路径
-	oldFilePath := filepath.Join(s.config.UploadDir, filepath.Base(oldPhoto.Path))
-	oldBase := filepath.Base(oldPhoto.Path)
 	oldExt := filepath.Ext(oldBase)
 	extLower := strings.ToLower(oldExt)
 	oldStem := strings.TrimSuffix(oldBase, oldExt)
@@ -408,20 +726,13 @@ func (s *PhotoService) EditPhoto(ctx context.Context, photoID, userID primitive.
 	}
 
 	newFileName := fmt.Sprintf("edit_%d_%s%s", time.Now().UnixNano(), oldStem, outExt)
-	newUploadPath := filepath.Join(s.config.UploadDir, newFileName)
+	newUploadPath := filepath.Join(workDir, newFileName)
 
 	// 调用工具类处理图片
 	if err := utils.EditImage(oldFilePath, newUploadPath, cropX, cropY, cropW, cropH, brightness, contrast, saturation); err != nil {
 		return nil, err
 	}
 
-	// 生成新缩略图并复用原 EXIF
-	thumbName := "thumb_" + newFileName
-	if err := utils.GenerateThumbnail(newUploadPath, filepath.Join(s.config.UploadDir, thumbName), 400); err != nil {
-		fmt.Printf("Warning: failed to generate thumbnail: %v\n", err)
-		thumbName = newFileName
-	}
-
 	info, err := os.Stat(newUploadPath)
 	if err != nil {
 		return nil, err
@@ -453,16 +764,41 @@ func (s *PhotoService) EditPhoto(ctx context.Context, photoID, userID primitive.
 		newMimeType = "image/tiff"
 	}
 
+	if err := s.putFile(ctx, newFileName, newUploadPath, newMimeType); err != nil {
+		return nil, fmt.Errorf("failed to store edited image: %w", err)
+	}
+
+	// 生成新缩略图与感知哈希，复用原 EXIF
+	var newPHash string
+	img, err := utils.OpenImage(newUploadPath)
+	if err != nil {
+		fmt.Printf("Warning: failed to decode edited image: %v\n", err)
+	} else {
+		newPHash = utils.PerceptualHash(img)
+	}
+	renditions, thumbName := s.storeRenditions(ctx, img, newUploadPath, workDir, strings.TrimSuffix(newFileName, outExt))
+	if thumbName == "" {
+		thumbName = newFileName
+	}
+
 	// 创建新文档记录 (非破坏性编辑：生成新图片)
 	newPhoto := *oldPhoto
 	newPhoto.ID = primitive.NilObjectID
 	newPhoto.Title = "编辑自: " + oldPhoto.Title
 	newPhoto.FileName = newFileName
-	newPhoto.Path = "/uploads/" + newFileName
-	newPhoto.ThumbPath = "/uploads/" + thumbName
+	newPhoto.Path = s.storage.URL(newFileName)
+	newPhoto.ThumbPath = s.storage.URL(thumbName)
 	newPhoto.Hash = newHash
+	newPhoto.PHash = newPHash
 	newPhoto.Size = info.Size()
 	newPhoto.MimeType = newMimeType
+	newPhoto.Renditions = renditions
+	if oldPhoto.Exif != nil {
+		// 编辑结果已按方向旋转为正向保存
+		exifCopy := *oldPhoto.Exif
+		exifCopy.Orientation = 0
+		newPhoto.Exif = &exifCopy
+	}
 
 	if err := s.repo.Create(ctx, &newPhoto); err != nil {
 		return nil, err
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"photoms/internal/models"
+	"photoms/pkg/utils"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidRenderOptions = errors.New("invalid render options")
+
+// RenderOptions 动态缩放/转码参数（GET /photos/:id/render?w=&h=&fit=&format=&q=）
+type RenderOptions struct {
+	Width   int
+	Height  int
+	Fit     string // "contain" / "cover"
+	Format  string // "jpeg" / "png" / "webp"
+	Quality int
+}
+
+// RenderedImage 渲染结果（已写入磁盘缓存）
+type RenderedImage struct {
+	Path        string
+	ContentType string
+	ETag        string
+	ModTime     time.Time
+}
+
+var renderContentTypes = map[string]string{
+	"jpeg": "image/jpeg",
+	"png":  "image/png",
+	"webp": "image/webp",
+}
+
+// renderLocks 同一缓存 key 的渲染串行执行，避免并发请求重复计算
+var renderLocks sync.Map
+
+// NormalizeRenderOptions 补全默认值并校验参数：宽高与质量必须在白名单内，防止任意参数组合撑满缓存
+func (s *PhotoService) NormalizeRenderOptions(opts RenderOptions) (RenderOptions, error) {
+	if opts.Width <= 0 && opts.Height <= 0 {
+		return opts, fmt.Errorf("%w: w or h is required", ErrInvalidRenderOptions)
+	}
+	if opts.Width > 0 && !slices.Contains(s.config.RenderAllowedSizes, opts.Width) {
+		return opts, fmt.Errorf("%w: w must be one of %v", ErrInvalidRenderOptions, s.config.RenderAllowedSizes)
+	}
+	if opts.Height > 0 && !slices.Contains(s.config.RenderAllowedSizes, opts.Height) {
+		return opts, fmt.Errorf("%w: h must be one of %v", ErrInvalidRenderOptions, s.config.RenderAllowedSizes)
+	}
+
+	opts.Fit = strings.ToLower(strings.TrimSpace(opts.Fit))
+	switch opts.Fit {
+	case "":
+		opts.Fit = "contain"
+	case "contain", "cover":
+	default:
+		return opts, fmt.Errorf("%w: fit must be contain or cover", ErrInvalidRenderOptions)
+	}
+	if opts.Width <= 0 || opts.Height <= 0 {
+		// 单边缩放时 fit 无意义，统一取值以共用缓存
+		opts.Fit = "contain"
+	}
+
+	opts.Format = strings.ToLower(strings.TrimSpace(opts.Format))
+	if opts.Format == "" || opts.Format == "jpg" {
+		opts.Format = "jpeg"
+	}
+	if _, ok := renderContentTypes[opts.Format]; !ok {
+		return opts, fmt.Errorf("%w: format must be jpeg, png or webp", ErrInvalidRenderOptions)
+	}
+
+	if opts.Format != "jpeg" {
+		// 质量参数只影响 jpeg，其它格式忽略以共用缓存
+		opts.Quality = 0
+	} else if opts.Quality <= 0 {
+		opts.Quality = s.config.RenderDefaultQuality
+	} else if !slices.Contains(s.config.RenderAllowedQualities, opts.Quality) {
+		return opts, fmt.Errorf("%w: q must be one of %v", ErrInvalidRenderOptions, s.config.RenderAllowedQualities)
+	}
+	return opts, nil
+}
+
+// RenderPhoto 按参数缩放/转码图片（验证所有权）。结果以 图片 Hash + 参数 为 key 缓存在 RenderCacheDir，
+// 同一内容的秒传副本共用缓存。
+func (s *PhotoService) RenderPhoto(ctx context.Context, photoID, userID primitive.ObjectID, opts RenderOptions) (*RenderedImage, error) {
+	opts, err := s.NormalizeRenderOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	photo, err := s.GetPhotoByID(ctx, photoID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := renderCacheKey(photo.Hash, opts)
+	cachePath := filepath.Join(s.config.RenderCacheDir, cacheKey[:2], cacheKey+"."+opts.Format)
+	rendered := &RenderedImage{
+		Path:        cachePath,
+		ContentType: renderContentTypes[opts.Format],
+		ETag:        `"` + cacheKey[:32] + `"`,
+	}
+
+	if info, err := os.Stat(cachePath); err == nil {
+		rendered.ModTime = info.ModTime()
+		return rendered, nil
+	}
+
+	v, _ := renderLocks.LoadOrStore(cacheKey, &sync.Mutex{})
+	mu := v.(*sync.Mutex)
+	mu.Lock()
+	defer func() {
+		mu.Unlock()
+		renderLocks.Delete(cacheKey)
+	}()
+
+	// 等锁期间可能已由其他请求生成
+	if info, err := os.Stat(cachePath); err == nil {
+		rendered.ModTime = info.ModTime()
+		return rendered, nil
+	}
+
+	if err := s.renderToCache(ctx, photo, opts, cachePath); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(cachePath)
+	if err != nil {
+		return nil, err
+	}
+	rendered.ModTime = info.ModTime()
+	return rendered, nil
+}
+
+func (s *PhotoService) renderToCache(ctx context.Context, photo *models.Photo, opts RenderOptions, cachePath string) error {
+	srcPath, cleanup, err := s.fetchToTemp(ctx, storageKey(s.renderSourcePath(photo, opts)))
+	if err != nil {
+		return fmt.Errorf("failed to load image: %w", err)
+	}
+	defer cleanup()
+
+	src, err := utils.OpenImage(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to open image: %w", err)
+	}
+	img := utils.RenderImage(src, opts.Width, opts.Height, opts.Fit)
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), os.ModePerm); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(cachePath), ".render-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if err := utils.EncodeImage(tmp, img, opts.Format, opts.Quality); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to encode image: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, cachePath); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+// renderSourcePath 选择渲染源：优先使用足够大的等比缩略图规格，避免每次都解码原图
+func (s *PhotoService) renderSourcePath(photo *models.Photo, opts RenderOptions) string {
+	best := photo.Path
+	bestWidth := 0
+	for _, spec := range s.config.ThumbnailRenditions {
+		if spec.Crop {
+			continue
+		}
+		r, ok := photo.Renditions[spec.Name]
+		if !ok || r.Width <= 0 || r.Height <= 0 {
+			continue
+		}
+		if r.Width < opts.Width || r.Height < opts.Height {
+			continue
+		}
+		if bestWidth == 0 || r.Width < bestWidth {
+			best, bestWidth = r.Path, r.Width
+		}
+	}
+	return best
+}
+
+// renderCacheVersion 渲染逻辑变化（如按 EXIF 方向旋转）时递增，使旧缓存失效
+const renderCacheVersion = 2
+
+func renderCacheKey(hash string, opts RenderOptions) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("v%d|%s|w=%d|h=%d|fit=%s|format=%s|q=%d",
+		renderCacheVersion, hash, opts.Width, opts.Height, opts.Fit, opts.Format, opts.Quality)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"errors"
+	"photoms/internal/models"
+	"photoms/pkg/config"
+	"testing"
+)
+
+func renderTestService() *PhotoService {
+	return &PhotoService{config: &config.Config{
+		RenderAllowedSizes:     []int{200, 400, 1280},
+		RenderAllowedQualities: []int{75, 85},
+		RenderDefaultQuality:   85,
+		ThumbnailRenditions: []config.RenditionSpec{
+			{Name: "sq200", Width: 200, Height: 200, Crop: true},
+			{Name: "w400", Width: 400},
+			{Name: "w1280", Width: 1280},
+		},
+	}}
+}
+
+func TestNormalizeRenderOptions(t *testing.T) {
+	s := renderTestService()
+	tests := []struct {
+		name string
+		in   RenderOptions
+		want RenderOptions
+	}{
+		{"defaults", RenderOptions{Width: 400}, RenderOptions{Width: 400, Fit: "contain", Format: "jpeg", Quality: 85}},
+		{"single side ignores fit", RenderOptions{Height: 200, Fit: "cover"}, RenderOptions{Height: 200, Fit: "contain", Format: "jpeg", Quality: 85}},
+		{"cover", RenderOptions{Width: 200, Height: 200, Fit: " Cover "}, RenderOptions{Width: 200, Height: 200, Fit: "cover", Format: "jpeg", Quality: 85}},
+		{"jpg alias", RenderOptions{Width: 400, Format: "JPG", Quality: 75}, RenderOptions{Width: 400, Fit: "contain", Format: "jpeg", Quality: 75}},
+		{"quality ignored for webp", RenderOptions{Width: 400, Format: "webp", Quality: 33}, RenderOptions{Width: 400, Fit: "contain", Format: "webp"}},
+	}
+	for _, tt := range tests {
+		got, err := s.NormalizeRenderOptions(tt.in)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	for _, in := range []RenderOptions{
+		{},
+		{Width: 401},
+		{Width: 400, Height: 4000},
+		{Width: 400, Fit: "fill"},
+		{Width: 400, Format: "gif"},
+		{Width: 400, Quality: 100},
+	} {
+		if _, err := s.NormalizeRenderOptions(in); !errors.Is(err, ErrInvalidRenderOptions) {
+			t.Errorf("%+v: err = %v, want ErrInvalidRenderOptions", in, err)
+		}
+	}
+}
+
+func TestRenderCacheKey(t *testing.T) {
+	base := RenderOptions{Width: 400, Fit: "contain", Format: "jpeg", Quality: 85}
+	key := renderCacheKey("hash", base)
+	if key != renderCacheKey("hash", base) {
+		t.Fatal("cache key is not deterministic")
+	}
+	for _, other := range []struct {
+		hash string
+		opts RenderOptions
+	}{
+		{"other", base},
+		{"hash", RenderOptions{Height: 400, Fit: "contain", Format: "jpeg", Quality: 85}},
+		{"hash", RenderOptions{Width: 400, Fit: "cover", Format: "jpeg", Quality: 85}},
+		{"hash", RenderOptions{Width: 400, Fit: "contain", Format: "png"}},
+		{"hash", RenderOptions{Width: 400, Fit: "contain", Format: "jpeg", Quality: 75}},
+	} {
+		if renderCacheKey(other.hash, other.opts) == key {
+			t.Errorf("%s %+v shares the cache key of %+v", other.hash, other.opts, base)
+		}
+	}
+}
+
+func TestRenderSourcePath(t *testing.T) {
+	s := renderTestService()
+	photo := &models.Photo{
+		Path: "/uploads/original.jpg",
+		Renditions: map[string]models.Rendition{
+			"sq200": {Path: "/uploads/sq200.jpg", Width: 200, Height: 200},
+			"w400":  {Path: "/uploads/w400.jpg", Width: 400, Height: 300},
+			"w1280": {Path: "/uploads/w1280.jpg", Width: 1280, Height: 960},
+		},
+	}
+	tests := []struct {
+		opts RenderOptions
+		want string
+	}{
+		{RenderOptions{Width: 200}, "/uploads/w400.jpg"}, // 裁剪规格不作为渲染源
+		{RenderOptions{Width: 400, Height: 300}, "/uploads/w400.jpg"},
+		{RenderOptions{Width: 400, Height: 400}, "/uploads/w1280.jpg"},
+		{RenderOptions{Width: 1600}, "/uploads/original.jpg"},
+	}
+	for _, tt := range tests {
+		if got := s.renderSourcePath(photo, tt.opts); got != tt.want {
+			t.Errorf("renderSourcePath(%+v) = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
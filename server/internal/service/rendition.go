@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"photoms/internal/models"
+	"photoms/pkg/config"
+	"photoms/pkg/utils"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// RenditionSpecs 返回当前配置的缩略图规格
+func (s *PhotoService) RenditionSpecs() []config.RenditionSpec {
+	return s.config.ThumbnailRenditions
+}
+
+// storeRenditions 按配置生成各规格缩略图并写入存储后端（key 为 <name>_<stem>.jpg），
+// 返回规格表与作为 ThumbPath 的缩略图 key。某个规格失败只记录警告；
+// 默认规格不可用时退回生成 400 宽的 thumb_<stem>.jpg，仍失败则返回空 key（调用方使用原图）。
+// src 为已解码（并按方向旋转）的图片，传 nil 时从 srcPath 解码。
+func (s *PhotoService) storeRenditions(ctx context.Context, src image.Image, srcPath, workDir, stem string) (map[string]models.Rendition, string) {
+	renditions := make(map[string]models.Rendition, len(s.config.ThumbnailRenditions))
+
+	var err error
+	if src == nil {
+		src, err = utils.OpenImage(srcPath)
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to open image for renditions: %v\n", err)
+	} else {
+		for _, spec := range s.config.ThumbnailRenditions {
+			key := fmt.Sprintf("%s_%s.jpg", spec.Name, stem)
+			localPath := filepath.Join(workDir, key)
+
+			img := utils.ResizeImage(src, spec.Width, spec.Height, spec.Crop)
+			if err := imaging.Save(img, localPath, imaging.JPEGQuality(85)); err != nil {
+				fmt.Printf("Warning: failed to generate rendition %s: %v\n", spec.Name, err)
+				continue
+			}
+			info, err := os.Stat(localPath)
+			if err != nil {
+				fmt.Printf("Warning: failed to generate rendition %s: %v\n", spec.Name, err)
+				continue
+			}
+			if err := s.putFile(ctx, key, localPath, "image/jpeg"); err != nil {
+				fmt.Printf("Warning: failed to store rendition %s: %v\n", spec.Name, err)
+				continue
+			}
+
+			b := img.Bounds()
+			renditions[spec.Name] = models.Rendition{
+				Path:   s.storage.URL(key),
+				Width:  b.Dx(),
+				Height: b.Dy(),
+				Size:   info.Size(),
+			}
+		}
+	}
+
+	if r, ok := renditions[s.config.ThumbnailDefaultRendition]; ok {
+		return nonEmptyRenditions(renditions), storageKey(r.Path)
+	}
+
+	thumbKey := fmt.Sprintf("thumb_%s.jpg", stem)
+	thumbLocalPath := filepath.Join(workDir, thumbKey)
+	if err := utils.GenerateThumbnail(srcPath, thumbLocalPath, 400); err != nil {
+		fmt.Printf("Warning: failed to generate thumbnail: %v\n", err)
+		return nonEmptyRenditions(renditions), ""
+	}
+	if err := s.putFile(ctx, thumbKey, thumbLocalPath, "image/jpeg"); err != nil {
+		fmt.Printf("Warning: failed to store thumbnail: %v\n", err)
+		return nonEmptyRenditions(renditions), ""
+	}
+	return nonEmptyRenditions(renditions), thumbKey
+}
+
+// RegenerateRenditions 重新读取原图 EXIF 方向并重新生成各规格缩略图，同步更新共用该文件的所有记录（秒传副本）。
+// force 为 false 时只处理需要旋转（Orientation > 1）或缺少规格表的图片；返回是否重新生成。
+func (s *PhotoService) RegenerateRenditions(ctx context.Context, photo *models.Photo, force bool) (bool, error) {
+	if photo == nil || photo.FileName == "" {
+		return false, nil
+	}
+
+	localPath, cleanup, err := s.fetchToTemp(ctx, photo.FileName)
+	if err != nil {
+		return false, fmt.Errorf("failed to load original image: %w", err)
+	}
+	defer cleanup()
+
+	orientation := 0
+	if exifInfo, _ := utils.ExtractExif(localPath); exifInfo != nil {
+		orientation = exifInfo.Orientation
+	}
+	if !force && orientation <= 1 && len(photo.Renditions) > 0 {
+		return false, nil
+	}
+
+	workDir, err := os.MkdirTemp("", "photoms-regen-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(workDir)
+
+	stem := strings.TrimSuffix(photo.FileName, filepath.Ext(photo.FileName))
+	renditions, thumbKey := s.storeRenditions(ctx, nil, localPath, workDir, stem)
+	if thumbKey == "" {
+		thumbKey = photo.FileName
+	}
+
+	update := bson.M{"thumb_path": s.storage.URL(thumbKey)}
+	if renditions != nil {
+		update["renditions"] = renditions
+	}
+	if orientation > 0 {
+		update["exif.orientation"] = orientation
+	}
+	if _, err := s.repo.UpdateByFileName(ctx, photo.FileName, update); err != nil {
+		return false, fmt.Errorf("failed to update photo: %w", err)
+	}
+
+	// 旧缩略图（如早期的 thumb_ 文件）不再被引用时删除
+	oldThumb := storageKey(photo.ThumbPath)
+	if oldThumb != "" && oldThumb != thumbKey && oldThumb != photo.FileName && !hasRenditionPath(&models.Photo{Renditions: renditions}, photo.ThumbPath) {
+		if err := s.storage.Delete(ctx, oldThumb); err != nil {
+			fmt.Printf("Warning: failed to delete old thumbnail %s: %v\n", oldThumb, err)
+		}
+	}
+	return true, nil
+}
+
+// deleteRenditions 删除图片各规格缩略图对应的存储文件
+func (s *PhotoService) deleteRenditions(ctx context.Context, photo *models.Photo) {
+	for name, r := range photo.Renditions {
+		key := storageKey(r.Path)
+		if key == "" || key == photo.FileName {
+			continue
+		}
+		if err := s.storage.Delete(ctx, key); err != nil {
+			fmt.Printf("Warning: failed to delete rendition %s (%s): %v\n", name, key, err)
+		}
+	}
+}
+
+// selectRenditions 按名称筛选规格表，names 为空时返回全部
+func selectRenditions(renditions map[string]models.Rendition, names []string) map[string]models.Rendition {
+	if len(names) == 0 {
+		return renditions
+	}
+	out := make(map[string]models.Rendition, len(names))
+	for _, name := range names {
+		if r, ok := renditions[name]; ok {
+			out[name] = r
+		}
+	}
+	return nonEmptyRenditions(out)
+}
+
+func nonEmptyRenditions(renditions map[string]models.Rendition) map[string]models.Rendition {
+	if len(renditions) == 0 {
+		return nil
+	}
+	return renditions
+}
+
+func hasRenditionPath(photo *models.Photo, webPath string) bool {
+	key := storageKey(webPath)
+	for _, r := range photo.Renditions {
+		if storageKey(r.Path) == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"photoms/internal/models"
+	"photoms/internal/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrShareNotFound         = errors.New("share not found")
+	ErrShareForbidden        = errors.New("unauthorized: share belongs to another user")
+	ErrShareExpired          = errors.New("share link has expired")
+	ErrSharePasswordRequired = errors.New("share link requires a password")
+	ErrSharePasswordInvalid  = errors.New("incorrect share password")
+	ErrShareDownloadDisabled = errors.New("downloading is not allowed for this share")
+	ErrInvalidShareTarget    = errors.New("exactly one of photoId or albumId is required")
+	ErrInvalidShareExpiry    = errors.New("expiresAt must be in the future")
+	ErrShareNotAlbum         = errors.New("share is not an album")
+	ErrPhotoNotInShare       = errors.New("photo is not part of this share")
+)
+
+// shareTokenBytes 分享 Token 的随机字节数（base64url 编码后 24 个字符）
+const shareTokenBytes = 18
+
+// CreateShareInput 创建分享链接的参数，PhotoID 与 AlbumID 必须且只能指定一个
+type CreateShareInput struct {
+	PhotoID       *primitive.ObjectID
+	AlbumID       *primitive.ObjectID
+	Password      string
+	ExpiresAt     *time.Time
+	AllowDownload bool
+}
+
+// ShareView 分享链接的所有者视图
+type ShareView struct {
+	*models.Share
+	HasPassword bool `json:"hasPassword"`
+	Expired     bool `json:"expired"`
+}
+
+// SharedAlbum 公开访问时返回的相册信息（不含所有者与图片 ID 列表）
+type SharedAlbum struct {
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	PhotoCount  int           `json:"photoCount"`
+	Cover       *models.Photo `json:"cover,omitempty"`
+}
+
+// SharedContent 公开访问分享链接的响应
+type SharedContent struct {
+	AllowDownload bool                `json:"allowDownload"`
+	ExpiresAt     *primitive.DateTime `json:"expiresAt,omitempty"`
+	Photo         *models.Photo       `json:"photo,omitempty"`
+	Album         *SharedAlbum        `json:"album,omitempty"`
+}
+
+type ShareService struct {
+	shareRepo    *repository.ShareRepository
+	photoService *PhotoService
+	albumService *AlbumService
+}
+
+func NewShareService(shareRepo *repository.ShareRepository, photoService *PhotoService, albumService *AlbumService) *ShareService {
+	return &ShareService{
+		shareRepo:    shareRepo,
+		photoService: photoService,
+		albumService: albumService,
+	}
+}
+
+// CreateShare 为自己的图片或相册创建分享链接
+func (s *ShareService) CreateShare(ctx context.Context, userID primitive.ObjectID, in CreateShareInput) (*ShareView, error) {
+	if (in.PhotoID == nil) == (in.AlbumID == nil) {
+		return nil, ErrInvalidShareTarget
+	}
+	if in.PhotoID != nil {
+		if _, err := s.photoService.getOwnedPhoto(ctx, *in.PhotoID, userID); err != nil {
+			return nil, err
+		}
+	}
+	if in.AlbumID != nil {
+		if _, err := s.albumService.getAlbum(ctx, *in.AlbumID, userID, models.AlbumRoleOwner); err != nil {
+			return nil, err
+		}
+	}
+	if in.ExpiresAt != nil && !in.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidShareExpiry
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		return nil, err
+	}
+	share := &models.Share{
+		UserID:        userID,
+		Token:         token,
+		PhotoID:       in.PhotoID,
+		AlbumID:       in.AlbumID,
+		AllowDownload: in.AllowDownload,
+	}
+	if in.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(in.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		share.PasswordHash = string(hash)
+	}
+	if in.ExpiresAt != nil {
+		dt := primitive.NewDateTimeFromTime(*in.ExpiresAt)
+		share.ExpiresAt = &dt
+	}
+
+	if err := s.shareRepo.Create(ctx, share); err != nil {
+		return nil, fmt.Errorf("failed to create share: %w", err)
+	}
+	return shareView(share), nil
+}
+
+// ListShares 分页列出自己创建的分享链接（“我的分享”）
+func (s *ShareService) ListShares(ctx context.Context, userID primitive.ObjectID, page, limit int64) ([]*ShareView, int64, error) {
+	shares, total, err := s.shareRepo.FindByUserID(ctx, userID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	views := make([]*ShareView, 0, len(shares))
+	for _, share := range shares {
+		views = append(views, shareView(share))
+	}
+	return views, total, nil
+}
+
+// RevokeShare 撤销分享链接（验证所有权），撤销后 Token 立即失效
+func (s *ShareService) RevokeShare(ctx context.Context, shareID, userID primitive.ObjectID) error {
+	share, err := s.shareRepo.FindByID(ctx, shareID)
+	if err != nil {
+		return ErrShareNotFound
+	}
+	if share.UserID != userID {
+		return ErrShareForbidden
+	}
+	return s.shareRepo.Delete(ctx, share.ID)
+}
+
+// ViewShare 公开访问分享链接并计数一次访问
+func (s *ShareService) ViewShare(ctx context.Context, token, password string) (*SharedContent, error) {
+	share, err := s.openShare(ctx, token, password)
+	if err != nil {
+		return nil, err
+	}
+
+	content := &SharedContent{AllowDownload: share.AllowDownload, ExpiresAt: share.ExpiresAt}
+	if share.PhotoID != nil {
+		photo, err := s.photoService.GetPhotoByID(ctx, *share.PhotoID, share.UserID)
+		if err != nil {
+			// 图片已被删除
+			return nil, ErrShareNotFound
+		}
+		content.Photo = s.sharedPhoto(share, photo)
+	} else {
+		album, err := s.albumService.GetAlbum(ctx, *share.AlbumID, share.UserID)
+		if err != nil {
+			return nil, ErrShareNotFound
+		}
+		content.Album = &SharedAlbum{
+			Title:       album.Title,
+			Description: album.Description,
+			PhotoCount:  album.PhotoCount,
+		}
+		if album.Cover != nil {
+			content.Album.Cover = s.sharedPhoto(share, album.Cover)
+		}
+	}
+
+	if err := s.shareRepo.IncrementViews(ctx, share.ID); err != nil {
+		fmt.Printf("Warning: failed to count view for share %s: %v\n", share.ID.Hex(), err)
+	}
+	return content, nil
+}
+
+// ListSharedPhotos 分页列出分享相册中的图片（按相册顺序）
+func (s *ShareService) ListSharedPhotos(ctx context.Context, token, password string, page, limit int64) ([]*models.Photo, int64, error) {
+	share, err := s.openShare(ctx, token, password)
+	if err != nil {
+		return nil, 0, err
+	}
+	if share.AlbumID == nil {
+		return nil, 0, ErrShareNotAlbum
+	}
+
+	photos, total, err := s.albumService.ListAlbumPhotos(ctx, *share.AlbumID, share.UserID, repository.PhotoFilter{}, page, limit)
+	if err != nil {
+		if errors.Is(err, ErrAlbumNotFound) {
+			return nil, 0, ErrShareNotFound
+		}
+		return nil, 0, err
+	}
+	out := make([]*models.Photo, 0, len(photos))
+	for _, photo := range photos {
+		out = append(out, s.sharedPhoto(share, photo))
+	}
+	return out, total, nil
+}
+
+// OpenSharedFile 读取分享中图片的文件；不允许下载时只能读取预览规格（见 isPreviewVariant）。
+// 相册分享需通过 photoID 指定图片。
+func (s *ShareService) OpenSharedFile(ctx context.Context, token, password string, photoID *primitive.ObjectID, variant string) (*MediaObject, error) {
+	share, err := s.openShare(ctx, token, password)
+	if err != nil {
+		return nil, err
+	}
+	if (variant == "" || variant == "original") && !share.AllowDownload {
+		return nil, ErrShareDownloadDisabled
+	}
+
+	target := share.PhotoID
+	if share.AlbumID != nil {
+		if photoID == nil {
+			return nil, ErrPhotoNotInShare
+		}
+		in, err := s.albumService.ContainsPhoto(ctx, *share.AlbumID, share.UserID, *photoID)
+		if err != nil {
+			if errors.Is(err, ErrAlbumNotFound) {
+				return nil, ErrShareNotFound
+			}
+			return nil, err
+		}
+		if !in {
+			return nil, ErrPhotoNotInShare
+		}
+		target = photoID
+	} else if photoID != nil && *photoID != *share.PhotoID {
+		return nil, ErrPhotoNotInShare
+	}
+
+	photo, err := s.photoService.GetPhotoByID(ctx, *target, share.UserID)
+	if err != nil {
+		return nil, ErrShareNotFound
+	}
+	if !share.AllowDownload && !s.photoService.isPreviewVariant(photo, variant) {
+		return nil, ErrShareDownloadDisabled
+	}
+	return s.photoService.openPhotoVariant(ctx, photo, variant)
+}
+
+// openShare 按 Token 查找分享并校验有效期与密码
+func (s *ShareService) openShare(ctx context.Context, token, password string) (*models.Share, error) {
+	if token == "" {
+		return nil, ErrShareNotFound
+	}
+	share, err := s.shareRepo.FindByToken(ctx, token)
+	if err != nil {
+		return nil, ErrShareNotFound
+	}
+	if shareExpired(share, time.Now()) {
+		return nil, ErrShareExpired
+	}
+	if share.PasswordHash != "" {
+		if password == "" {
+			return nil, ErrSharePasswordRequired
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(share.PasswordHash), []byte(password)); err != nil {
+			return nil, ErrSharePasswordInvalid
+		}
+	}
+	return share, nil
+}
+
+// sharedPhoto 生成公开访问的图片视图：去掉文件名、Hash、选片标记、GPS 与拍摄地点等内部/隐私信息；
+// 不允许下载时不返回原图地址，缩略图也只保留预览规格
+func (s *ShareService) sharedPhoto(share *models.Share, photo *models.Photo) *models.Photo {
+	out := s.photoService.PresentPhoto(photo)
+	out.FileName = ""
+	out.Hash = ""
+	out.PHash = ""
+	// 星级、收藏与颜色标签是所有者的选片标记，不公开
+	out.Rating = 0
+	out.Favorite = false
+	out.ColorLabel = ""
+	if !share.AllowDownload {
+		out.Path = ""
+		if !s.photoService.isPreviewVariant(photo, "thumb") {
+			out.ThumbPath = ""
+		}
+		for name := range out.Renditions {
+			if !s.photoService.isPreviewVariant(photo, name) {
+				delete(out.Renditions, name)
+			}
+		}
+	}
+	if out.Exif != nil {
+		exif := *out.Exif
+		exif.GPS = nil
+		out.Exif = &exif
+	}
+	if out.Place != nil {
+		out.Tags = withoutPlaceTags(out.Tags, out.Place)
+		out.Place = nil
+	}
+	return out
+}
+
+func shareView(share *models.Share) *ShareView {
+	return &ShareView{
+		Share:       share,
+		HasPassword: share.PasswordHash != "",
+		Expired:     shareExpired(share, time.Now()),
+	}
+}
+
+func shareExpired(share *models.Share, now time.Time) bool {
+	return share.ExpiresAt != nil && !share.ExpiresAt.Time().After(now)
+}
+
+func newShareToken() (string, error) {
+	b := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"photoms/internal/models"
+	"photoms/internal/repository"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidSmartQuery  = errors.New("invalid smart album query")
+	ErrSmartAlbumReadOnly = errors.New("photos in a smart album are resolved from its query and cannot be edited")
+)
+
+// maxSmartQueryTags 智能相册查询中最多的标签数
+const maxSmartQueryTags = 20
+
+// normalizeSmartQuery 清理并校验智能相册查询：去除空白与重复标签，检查检索语句、日期、评分范围与颜色标签，至少需要一个条件
+func normalizeSmartQuery(q *models.SmartQuery) (*models.SmartQuery, error) {
+	if q == nil {
+		return nil, fmt.Errorf("%w: query is required", ErrInvalidSmartQuery)
+	}
+
+	out := &models.SmartQuery{
+		Q:         strings.TrimSpace(q.Q),
+		StartDate: q.StartDate,
+		EndDate:   q.EndDate,
+		DateField: strings.TrimSpace(q.DateField),
+		Make:      strings.TrimSpace(q.Make),
+		Model:     strings.TrimSpace(q.Model),
+		HasGPS:    q.HasGPS,
+		MinRating: q.MinRating,
+		Favorite:  q.Favorite,
+	}
+
+	seen := make(map[string]struct{}, len(q.Tags))
+	for _, tag := range q.Tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		out.Tags = append(out.Tags, tag)
+	}
+	if len(out.Tags) > maxSmartQueryTags {
+		return nil, fmt.Errorf("%w: at most %d tags", ErrInvalidSmartQuery, maxSmartQueryTags)
+	}
+	if err := repository.ParsePhotoQuery(out.Q, &repository.PhotoFilter{}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSmartQuery, err)
+	}
+
+	if out.StartDate != nil && out.EndDate != nil && *out.StartDate > *out.EndDate {
+		return nil, fmt.Errorf("%w: startDate must not be after endDate", ErrInvalidSmartQuery)
+	}
+	if out.DateField != "" && out.DateField != repository.DateFieldUploaded && out.DateField != repository.DateFieldTaken {
+		return nil, fmt.Errorf("%w: dateField must be uploaded or taken", ErrInvalidSmartQuery)
+	}
+	if out.MinRating != nil && (*out.MinRating < 0 || *out.MinRating > 5) {
+		return nil, fmt.Errorf("%w: minRating must be between 0 and 5", ErrInvalidSmartQuery)
+	}
+
+	label, err := NormalizeColorLabelFilter(q.ColorLabel)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown color label %q", ErrInvalidSmartQuery, q.ColorLabel)
+	}
+	out.ColorLabel = label
+
+	if out.Q == "" && len(out.Tags) == 0 && out.StartDate == nil && out.EndDate == nil &&
+		out.Make == "" && out.Model == "" && out.HasGPS == nil && out.MinRating == nil &&
+		out.Favorite == nil && out.ColorLabel == "" {
+		return nil, fmt.Errorf("%w: at least one condition is required", ErrInvalidSmartQuery)
+	}
+	return out, nil
+}
+
+// smartAlbumFilter 将智能相册的查询转换为图片过滤条件（仅限相册所有者的图片）。
+// 检索语句在保存时已校验；早于校验保存、无法解析的语句整体按关键词匹配
+func smartAlbumFilter(album *models.Album) repository.PhotoFilter {
+	filter, err := smartQueryFilter(album.UserID, album.Query)
+	if err != nil {
+		filter.Q = album.Query.Q
+	}
+	return filter
+}
+
+// smartQueryFilter 将查询条件转换为 userID 名下图片的过滤条件，q.Q 按检索语句解析（见 repository.ParsePhotoQuery），
+// 其中的条件覆盖同类字段；解析失败时返回 ErrInvalidSmartQuery 及其余条件组成的过滤条件
+func smartQueryFilter(userID primitive.ObjectID, q *models.SmartQuery) (repository.PhotoFilter, error) {
+	filter := repository.PhotoFilter{UserID: &userID}
+	if q == nil {
+		return filter, nil
+	}
+
+	filter.Tags = q.Tags
+	filter.StartDate = dateTimePtr(q.StartDate)
+	filter.EndDate = dateTimePtr(q.EndDate)
+	filter.DateField = q.DateField
+	filter.Make = q.Make
+	filter.Model = q.Model
+	filter.HasGPS = q.HasGPS
+	filter.MinRating = q.MinRating
+	filter.Favorite = q.Favorite
+	filter.ColorLabel = q.ColorLabel
+
+	parsed := filter
+	parsed.Tags = append([]string(nil), filter.Tags...)
+	if err := repository.ParsePhotoQuery(q.Q, &parsed); err != nil {
+		return filter, fmt.Errorf("%w: %v", ErrInvalidSmartQuery, err)
+	}
+	return parsed, nil
+}
+
+func dateTimePtr(dt *primitive.DateTime) *time.Time {
+	if dt == nil {
+		return nil
+	}
+	t := dt.Time()
+	return &t
+}
+
+func isSmartAlbum(album *models.Album) bool {
+	return album.Type == models.AlbumTypeSmart
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"photoms/internal/models"
+	"photoms/internal/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrPhotoInTrash    = errors.New("photo is in trash")
+	ErrPhotoNotInTrash = errors.New("photo is not in trash")
+)
+
+// DeletePhoto 将图片移入回收站（保留文件与相册关系，可恢复）；
+// 超过 TrashRetentionDays 后由后台任务彻底删除
+func (s *PhotoService) DeletePhoto(ctx context.Context, photoID, userID primitive.ObjectID) error {
+	// 先验证所有权
+	photo, err := s.getOwnedPhoto(ctx, photoID, userID)
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.repo.MoveToTrash(ctx, photo.ID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to move photo to trash: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("photo not found: %w", ErrPhotoInTrash)
+	}
+	return nil
+}
+
+// ListTrash 分页列出回收站中的图片（最近删除的在前）
+func (s *PhotoService) ListTrash(ctx context.Context, userID primitive.ObjectID, page, limit int64) ([]*models.Photo, int64, error) {
+	return s.repo.Find(ctx, repository.PhotoFilter{UserID: &userID, Trashed: true}, page, limit)
+}
+
+// RestorePhoto 从回收站恢复图片
+func (s *PhotoService) RestorePhoto(ctx context.Context, photoID, userID primitive.ObjectID) (*models.Photo, error) {
+	photo, err := s.getTrashedPhoto(ctx, photoID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.repo.Restore(ctx, photo.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore photo: %w", err)
+	}
+	if !ok {
+		return nil, ErrPhotoNotInTrash
+	}
+	return s.repo.FindByID(ctx, photo.ID)
+}
+
+// PurgePhoto 立即彻底删除回收站中的一张图片
+func (s *PhotoService) PurgePhoto(ctx context.Context, photoID, userID primitive.ObjectID) error {
+	photo, err := s.getTrashedPhoto(ctx, photoID, userID)
+	if err != nil {
+		return err
+	}
+	return s.purgePhoto(ctx, photo)
+}
+
+// EmptyTrash 清空回收站，返回彻底删除的图片数量
+func (s *PhotoService) EmptyTrash(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	filter := bson.M{"user_id": userID, "deleted_at": bson.M{"$exists": true}}
+	return s.purgeMatching(ctx, filter)
+}
+
+// PurgeExpiredTrash 彻底删除所有超过保留期的回收站图片，返回删除数量
+func (s *PhotoService) PurgeExpiredTrash(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-time.Duration(s.config.TrashRetentionDays) * 24 * time.Hour)
+	filter := bson.M{"deleted_at": bson.M{"$lte": primitive.NewDateTimeFromTime(cutoff)}}
+	return s.purgeMatching(ctx, filter)
+}
+
+// StartTrashPurger 在后台定期清理超过保留期的回收站图片，ctx 取消时退出。
+// TrashRetentionDays <= 0 时不自动清理。
+func (s *PhotoService) StartTrashPurger(ctx context.Context) {
+	if s.config.TrashRetentionDays <= 0 {
+		return
+	}
+	interval := time.Duration(s.config.TrashPurgeIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := s.PurgeExpiredTrash(ctx)
+				if err != nil {
+					fmt.Printf("Warning: trash purge failed: %v\n", err)
+				} else if n > 0 {
+					fmt.Printf("Purged %d photos from trash\n", n)
+				}
+			}
+		}
+	}()
+}
+
+func (s *PhotoService) getTrashedPhoto(ctx context.Context, photoID, userID primitive.ObjectID) (*models.Photo, error) {
+	photo, err := s.repo.FindByID(ctx, photoID)
+	if err != nil {
+		return nil, fmt.Errorf("photo not found: %w", err)
+	}
+	if photo.UserID != userID {
+		return nil, fmt.Errorf("unauthorized: photo belongs to another user")
+	}
+	if photo.DeletedAt == nil {
+		return nil, ErrPhotoNotInTrash
+	}
+	return photo, nil
+}
+
+func (s *PhotoService) purgeMatching(ctx context.Context, filter bson.M) (int, error) {
+	purged := 0
+	err := s.repo.ForEach(ctx, filter, func(photo *models.Photo) error {
+		if err := s.purgePhoto(ctx, photo); err != nil {
+			fmt.Printf("Warning: failed to purge photo %s: %v\n", photo.ID.Hex(), err)
+			return nil
+		}
+		purged++
+		return nil
+	})
+	return purged, err
+}
+
+// purgePhoto 彻底删除图片（包括文件和数据库记录）
+func (s *PhotoService) purgePhoto(ctx context.Context, photo *models.Photo) error {
+	// 先删除数据库记录，避免文件已删除但数据库删除失败
+	if err := s.repo.Delete(ctx, photo.ID); err != nil {
+		return fmt.Errorf("failed to delete photo from database: %w", err)
+	}
+
+	// 从所有相册中移除
+	if err := s.albumRepo.RemovePhotoFromAll(ctx, photo.ID); err != nil {
+		fmt.Printf("Warning: failed to remove photo %s from albums: %v\n", photo.ID.Hex(), err)
+	}
+
+	// 若该文件被其他记录复用（秒传/重复上传，包括回收站中的记录），则跳过磁盘清理
+	if photo.FileName == "" {
+		return nil
+	}
+
+	remaining, err := s.repo.CountByFileName(ctx, photo.FileName)
+	if err != nil {
+		fmt.Printf("Warning: failed to count file references for %s: %v\n", photo.FileName, err)
+		return nil
+	}
+	if remaining > 0 {
+		return nil
+	}
+
+	// 删除存储中的文件
+	if err := s.storage.Delete(ctx, photo.FileName); err != nil {
+		fmt.Printf("Warning: failed to delete file %s: %v\n", photo.FileName, err)
+	}
+
+	// 删除缩略图（如果与原图不同，且不是已随规格表删除的文件）
+	s.deleteRenditions(ctx, photo)
+	if photo.ThumbPath != "" && photo.ThumbPath != photo.Path && !hasRenditionPath(photo, photo.ThumbPath) {
+		thumbKey := storageKey(photo.ThumbPath)
+		if err := s.storage.Delete(ctx, thumbKey); err != nil {
+			fmt.Printf("Warning: failed to delete thumbnail %s: %v\n", thumbKey, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"photoms/internal/models"
+	"photoms/internal/repository"
+	"photoms/pkg/config"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrUploadSessionNotFound  = errors.New("upload session not found")
+	ErrUploadSessionForbidden = errors.New("unauthorized: upload session belongs to another user")
+	ErrUploadSessionExpired   = errors.New("upload session expired")
+	ErrUploadSessionCompleted = errors.New("upload session already completed")
+	ErrUploadOffsetMismatch   = errors.New("upload offset mismatch")
+	ErrUploadExceedsLength    = errors.New("upload exceeds declared length")
+	ErrUploadIncomplete       = errors.New("upload is incomplete")
+)
+
+const (
+	uploadStatusUploading = "UPLOADING"
+	uploadStatusCompleted = "COMPLETED"
+)
+
+// UploadService 实现 tus 风格的断点续传：创建会话 -> 按 offset 追加分块 -> 查询进度 -> 完成后导入图片库
+type UploadService struct {
+	repo         *repository.UploadSessionRepository
+	photoService *PhotoService
+	config       *config.Config
+
+	// 同一会话的分块写入、完成与清理需串行执行
+	locks *keyedMutex
+}
+
+func NewUploadService(repo *repository.UploadSessionRepository, photoService *PhotoService, cfg *config.Config) *UploadService {
+	return &UploadService{repo: repo, photoService: photoService, config: cfg, locks: newKeyedMutex()}
+}
+
+// CreateSession 创建上传会话并预先建立空的暂存文件
+func (s *UploadService) CreateSession(ctx context.Context, userID primitive.ObjectID, fileName, mimeType string, size int64) (*models.UploadSession, error) {
+	fileName = strings.TrimSpace(filepath.Base(fileName))
+	if fileName == "" || fileName == "." || fileName == "/" {
+		return nil, fmt.Errorf("fileName is required")
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("size must be greater than 0")
+	}
+	if err := s.photoService.CheckUploadSize(size); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(s.config.UploadSessionDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	session := &models.UploadSession{
+		UserID:    userID,
+		FileName:  fileName,
+		MimeType:  strings.TrimSpace(mimeType),
+		Size:      size,
+		Offset:    0,
+		Status:    uploadStatusUploading,
+		ExpiresAt: primitive.NewDateTimeFromTime(s.nextExpiry()),
+	}
+	if err := s.repo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(s.stagingPath(session.ID))
+	if err != nil {
+		_ = s.repo.Delete(ctx, session.ID)
+		return nil, err
+	}
+	f.Close()
+
+	return session, nil
+}
+
+// GetSession 获取会话（验证用户所有权与有效期）
+func (s *UploadService) GetSession(ctx context.Context, sessionID, userID primitive.ObjectID) (*models.UploadSession, error) {
+	session, err := s.repo.FindByID(ctx, sessionID)
+	if err != nil {
+		return nil, ErrUploadSessionNotFound
+	}
+	if session.UserID != userID {
+		return nil, ErrUploadSessionForbidden
+	}
+	if session.Status != uploadStatusCompleted && session.ExpiresAt.Time().Before(time.Now()) {
+		return nil, ErrUploadSessionExpired
+	}
+	return session, nil
+}
+
+// AppendChunk 从 offset 处追加一个分块，返回写入后的 offset。
+// 连接中断时已写入的部分仍会被记录，客户端可通过 HEAD 查询后从新的 offset 继续。
+func (s *UploadService) AppendChunk(ctx context.Context, sessionID, userID primitive.ObjectID, offset int64, body io.Reader) (int64, error) {
+	unlock := s.lock(sessionID)
+	defer unlock()
+
+	session, err := s.GetSession(ctx, sessionID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if session.Status == uploadStatusCompleted {
+		return session.Offset, ErrUploadSessionCompleted
+	}
+	if offset != session.Offset {
+		return session.Offset, ErrUploadOffsetMismatch
+	}
+
+	f, err := os.OpenFile(s.stagingPath(session.ID), os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return session.Offset, err
+	}
+	defer f.Close()
+
+	// 丢弃上次中断时可能残留在 offset 之后的数据
+	if err := f.Truncate(offset); err != nil {
+		return session.Offset, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return session.Offset, err
+	}
+
+	remaining := session.Size - offset
+	written, copyErr := io.Copy(f, io.LimitReader(body, remaining+1))
+	if written > remaining {
+		// 超出声明长度：回滚本次写入
+		_ = f.Truncate(offset)
+		return session.Offset, ErrUploadExceedsLength
+	}
+
+	newOffset := offset + written
+	if written > 0 {
+		ok, err := s.repo.AdvanceOffset(ctx, session.ID, offset, newOffset, s.nextExpiry())
+		if err != nil {
+			return session.Offset, err
+		}
+		if !ok {
+			return session.Offset, ErrUploadOffsetMismatch
+		}
+	}
+	if copyErr != nil {
+		return newOffset, copyErr
+	}
+	return newOffset, nil
+}
+
+// Complete 校验数据已全部上传后，将暂存文件导入图片库（与普通上传共用 Hash/EXIF/缩略图流程）
+func (s *UploadService) Complete(ctx context.Context, sessionID, userID primitive.ObjectID) (*models.Photo, error) {
+	unlock := s.lock(sessionID)
+	defer unlock()
+
+	session, err := s.GetSession(ctx, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重复调用完成接口时直接返回已创建的图片
+	if session.Status == uploadStatusCompleted && session.PhotoID != nil {
+		return s.photoService.GetPhotoByID(ctx, *session.PhotoID, userID)
+	}
+	if session.Offset != session.Size {
+		return nil, ErrUploadIncomplete
+	}
+
+	// 图片 ID 先记录在会话上再导入：上次完成时图片已创建但未能标记会话，重试时直接使用该图片，
+	// 不会重复导入；图片尚未创建时以同一 ID 重新导入
+	if session.PhotoID != nil {
+		photo, err := s.photoService.GetPhotoByID(ctx, *session.PhotoID, userID)
+		if err == nil {
+			if err := s.finishSession(ctx, session, photo); err != nil {
+				return nil, err
+			}
+			return photo, nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	} else {
+		photoID := primitive.NewObjectID()
+		if err := s.repo.SetPhotoID(ctx, session.ID, photoID); err != nil {
+			return nil, err
+		}
+		session.PhotoID = &photoID
+	}
+
+	f, err := os.Open(s.stagingPath(session.ID))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	photo, err := s.photoService.ImportPhoto(ctx, userID, *session.PhotoID, session.FileName, f)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	if err := s.finishSession(ctx, session, photo); err != nil {
+		return nil, err
+	}
+	return photo, nil
+}
+
+// finishSession 将会话标记为完成并删除暂存文件；标记失败时保留暂存文件，以便重试。
+// 完成的会话再保留一个有效期，期间重复调用完成接口返回已创建的图片，之后由清理任务删除
+func (s *UploadService) finishSession(ctx context.Context, session *models.UploadSession, photo *models.Photo) error {
+	if err := s.repo.MarkCompleted(ctx, session.ID, photo.ID, s.nextExpiry()); err != nil {
+		return fmt.Errorf("failed to mark upload session completed: %w", err)
+	}
+	stagingPath := s.stagingPath(session.ID)
+	if err := os.Remove(stagingPath); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Warning: failed to remove staging file %s: %v\n", stagingPath, err)
+	}
+	return nil
+}
+
+// Abort 取消上传并删除暂存数据
+func (s *UploadService) Abort(ctx context.Context, sessionID, userID primitive.ObjectID) error {
+	unlock := s.lock(sessionID)
+	defer unlock()
+
+	session, err := s.repo.FindByID(ctx, sessionID)
+	if err != nil {
+		return ErrUploadSessionNotFound
+	}
+	if session.UserID != userID {
+		return ErrUploadSessionForbidden
+	}
+	s.removeSession(ctx, session)
+	return nil
+}
+
+// CleanupExpired 删除已过期的会话（包括超过保留期的已完成会话）及其暂存文件，返回清理数量
+func (s *UploadService) CleanupExpired(ctx context.Context) (int, error) {
+	sessions, err := s.repo.FindExpired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, session := range sessions {
+		if s.removeIfExpired(ctx, session.ID) {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// removeIfExpired 持有会话锁后重新读取会话，仍过期时才删除（查询之后可能有分块写入延长了有效期）
+func (s *UploadService) removeIfExpired(ctx context.Context, id primitive.ObjectID) bool {
+	unlock := s.lock(id)
+	defer unlock()
+
+	session, err := s.repo.FindByID(ctx, id)
+	if err != nil || !session.ExpiresAt.Time().Before(time.Now()) {
+		return false
+	}
+	s.removeSession(ctx, session)
+	return true
+}
+
+// StartCleanup 在后台定期清理被放弃的上传会话，ctx 取消时退出
+func (s *UploadService) StartCleanup(ctx context.Context) {
+	interval := time.Duration(s.config.UploadSessionCleanupMinutes) * time.Minute
+	if interval <= 0 {
+		interval = 30 * time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := s.CleanupExpired(ctx)
+				if err != nil {
+					fmt.Printf("Warning: upload session cleanup failed: %v\n", err)
+				} else if n > 0 {
+					fmt.Printf("Cleaned up %d expired upload sessions\n", n)
+				}
+			}
+		}
+	}()
+}
+
+func (s *UploadService) removeSession(ctx context.Context, session *models.UploadSession) {
+	stagingPath := s.stagingPath(session.ID)
+	if err := os.Remove(stagingPath); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Warning: failed to remove staging file %s: %v\n", stagingPath, err)
+	}
+	if err := s.repo.Delete(ctx, session.ID); err != nil {
+		fmt.Printf("Warning: failed to delete upload session %s: %v\n", session.ID.Hex(), err)
+	}
+}
+
+func (s *UploadService) stagingPath(id primitive.ObjectID) string {
+	return filepath.Join(s.config.UploadSessionDir, id.Hex()+".part")
+}
+
+func (s *UploadService) nextExpiry() time.Time {
+	ttl := time.Duration(s.config.UploadSessionTTLHours) * time.Hour
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return time.Now().Add(ttl)
+}
+
+func (s *UploadService) lock(id primitive.ObjectID) func() {
+	return s.locks.lock(id.Hex())
+}
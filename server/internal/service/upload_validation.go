@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"photoms/pkg/utils"
+	"strings"
+)
+
+var (
+	ErrFileTooLarge         = errors.New("file too large")
+	ErrUnsupportedImageType = errors.New("unsupported image type")
+	ErrImageTooLarge        = errors.New("image dimensions too large")
+	ErrInvalidImage         = errors.New("invalid or corrupted image")
+)
+
+// IsUploadRejection 判断错误是否为上传内容校验失败（应返回 4xx 而非 500）
+func IsUploadRejection(err error) bool {
+	return errors.Is(err, ErrFileTooLarge) ||
+		errors.Is(err, ErrUnsupportedImageType) ||
+		errors.Is(err, ErrImageTooLarge) ||
+		errors.Is(err, ErrInvalidImage)
+}
+
+// MaxUploadBytes 单个文件允许的最大字节数（<=0 表示不限制）
+func (s *PhotoService) MaxUploadBytes() int64 {
+	if s.config == nil || s.config.UploadMaxSizeMB <= 0 {
+		return 0
+	}
+	return int64(s.config.UploadMaxSizeMB) * 1024 * 1024
+}
+
+// CheckUploadSize 在读取文件内容之前按声明大小快速拒绝超限文件
+func (s *PhotoService) CheckUploadSize(size int64) error {
+	if max := s.MaxUploadBytes(); max > 0 && size > max {
+		return fmt.Errorf("%w: %d bytes (max %d)", ErrFileTooLarge, size, max)
+	}
+	return nil
+}
+
+// validateImageFile 通过 magic bytes 识别真实类型并校验白名单与像素数，
+// 返回服务端识别出的 MIME 类型与规范扩展名（不信任客户端提供的 Content-Type/扩展名）
+func (s *PhotoService) validateImageFile(localPath string) (string, string, error) {
+	mimeType, ext, err := utils.SniffImageFile(localPath)
+	if err != nil {
+		return "", "", err
+	}
+	if mimeType == "" {
+		return "", "", fmt.Errorf("%w: unrecognized file content", ErrUnsupportedImageType)
+	}
+	if !s.isAllowedType(mimeType) {
+		return "", "", fmt.Errorf("%w: %s", ErrUnsupportedImageType, mimeType)
+	}
+
+	width, height, err := utils.ImageDimensions(localPath)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if width <= 0 || height <= 0 {
+		return "", "", fmt.Errorf("%w: empty image", ErrInvalidImage)
+	}
+	if s.config != nil && s.config.UploadMaxMegapixels > 0 {
+		maxPixels := int64(s.config.UploadMaxMegapixels) * 1000 * 1000
+		if int64(width)*int64(height) > maxPixels {
+			return "", "", fmt.Errorf("%w: %dx%d exceeds %d megapixels", ErrImageTooLarge, width, height, s.config.UploadMaxMegapixels)
+		}
+	}
+
+	return mimeType, ext, nil
+}
+
+func (s *PhotoService) isAllowedType(mimeType string) bool {
+	if s.config == nil || len(s.config.UploadAllowedTypes) == 0 {
+		return true
+	}
+	for _, allowed := range s.config.UploadAllowedTypes {
+		if strings.EqualFold(strings.TrimSpace(allowed), mimeType) {
+			return true
+		}
+	}
+	return false
+}
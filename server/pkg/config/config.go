@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"strconv"
@@ -15,6 +16,49 @@ type Config struct {
 	UploadDir      string
 	AllowedOrigins []string
 
+	// File storage backend: "local" (UploadDir) or "s3" (S3-compatible, e.g. MinIO)
+	StorageDriver string
+	S3Endpoint    string
+	S3Region      string
+	S3Bucket      string
+	S3Prefix      string
+	S3AccessKey   string
+	S3SecretKey   string
+	S3UseSSL      bool
+	S3PublicURL   string
+
+	// Private media delivery (HMAC-signed, expiring URLs)
+	MediaSigningKey    string
+	MediaURLTTLMinutes int
+
+	// Thumbnail renditions generated at upload/edit; ThumbPath points at ThumbnailDefaultRendition
+	ThumbnailRenditions       []RenditionSpec
+	ThumbnailDefaultRendition string
+
+	// On-the-fly render endpoint (allowlisted sizes/qualities, disk cache)
+	RenderCacheDir         string
+	RenderAllowedSizes     []int
+	RenderAllowedQualities []int
+	RenderDefaultQuality   int
+
+	// Upload validation
+	UploadMaxSizeMB     int
+	UploadMaxMegapixels int
+	UploadAllowedTypes  []string
+
+	// Batch uploads
+	BatchUploadConcurrency int
+	BatchUploadMaxFiles    int
+
+	// Resumable (chunked) uploads
+	UploadSessionDir            string
+	UploadSessionTTLHours       int
+	UploadSessionCleanupMinutes int
+
+	// Trash (soft delete)
+	TrashRetentionDays        int
+	TrashPurgeIntervalMinutes int
+
 	// AI image tagging (optional)
 	AITaggingEnabled    bool
 	AIProvider          string
@@ -28,7 +72,7 @@ type Config struct {
 }
 
 func Load() *Config {
-	return &Config{
+	cfg := &Config{
 		Port:         getEnv("PORT", "8080"),
 		MongoURI:     getEnv("MONGO_URI", "mongodb://localhost:27017"),
 		DatabaseName: getEnv("DATABASE_NAME", "photoms"),
@@ -38,6 +82,41 @@ func Load() *Config {
 			getEnv("CLIENT_URL", "http://localhost:5173"),
 		},
 
+		StorageDriver: getEnv("STORAGE_DRIVER", "local"),
+		S3Endpoint:    strings.TrimSpace(os.Getenv("S3_ENDPOINT")),
+		S3Region:      strings.TrimSpace(os.Getenv("S3_REGION")),
+		S3Bucket:      getEnv("S3_BUCKET", "photoms"),
+		S3Prefix:      strings.TrimSpace(os.Getenv("S3_PREFIX")),
+		S3AccessKey:   strings.TrimSpace(os.Getenv("S3_ACCESS_KEY")),
+		S3SecretKey:   strings.TrimSpace(os.Getenv("S3_SECRET_KEY")),
+		S3UseSSL:      getEnvBool("S3_USE_SSL", false),
+		S3PublicURL:   strings.TrimSpace(os.Getenv("S3_PUBLIC_URL")),
+
+		MediaSigningKey:    strings.TrimSpace(os.Getenv("MEDIA_SIGNING_KEY")),
+		MediaURLTTLMinutes: getEnvInt("MEDIA_URL_TTL_MINUTES", 60),
+
+		ThumbnailRenditions:       getEnvRenditions("THUMBNAIL_RENDITIONS", defaultThumbnailRenditions()),
+		ThumbnailDefaultRendition: getEnv("THUMBNAIL_DEFAULT_RENDITION", "w400"),
+
+		RenderCacheDir:         getEnv("RENDER_CACHE_DIR", "./render_cache"),
+		RenderAllowedSizes:     getEnvIntCSV("RENDER_ALLOWED_SIZES", []int{64, 128, 200, 256, 320, 400, 480, 640, 800, 1024, 1280, 1600, 1920, 2048}),
+		RenderAllowedQualities: getEnvIntCSV("RENDER_ALLOWED_QUALITIES", []int{60, 75, 85, 95}),
+		RenderDefaultQuality:   getEnvInt("RENDER_DEFAULT_QUALITY", 85),
+
+		UploadMaxSizeMB:     getEnvInt("UPLOAD_MAX_SIZE_MB", 100),
+		UploadMaxMegapixels: getEnvInt("UPLOAD_MAX_MEGAPIXELS", 100),
+		UploadAllowedTypes:  getEnvCSV("UPLOAD_ALLOWED_TYPES", defaultUploadAllowedTypes()),
+
+		BatchUploadConcurrency: getEnvInt("BATCH_UPLOAD_CONCURRENCY", 4),
+		BatchUploadMaxFiles:    getEnvInt("BATCH_UPLOAD_MAX_FILES", 500),
+
+		UploadSessionDir:            getEnv("UPLOAD_SESSION_DIR", "./upload_sessions"),
+		UploadSessionTTLHours:       getEnvInt("UPLOAD_SESSION_TTL_HOURS", 24),
+		UploadSessionCleanupMinutes: getEnvInt("UPLOAD_SESSION_CLEANUP_MINUTES", 30),
+
+		TrashRetentionDays:        getEnvInt("TRASH_RETENTION_DAYS", 30),
+		TrashPurgeIntervalMinutes: getEnvInt("TRASH_PURGE_INTERVAL_MINUTES", 60),
+
 		AITaggingEnabled:    getEnvBool("AI_TAGGING_ENABLED", false),
 		AIProvider:          getEnv("AI_PROVIDER", "ark"),
 		ArkAPIKey:           strings.TrimSpace(os.Getenv("ARK_API_KEY")),
@@ -48,6 +127,12 @@ func Load() *Config {
 		AITagMaxTags:        getEnvInt("AI_TAG_MAX_TAGS", 5),
 		AITagTimeoutSeconds: getEnvInt("AI_TAG_TIMEOUT_SECONDS", 20),
 	}
+
+	// 未单独配置签名密钥时复用 JWT_SECRET
+	if cfg.MediaSigningKey == "" {
+		cfg.MediaSigningKey = cfg.JWTSecret
+	}
+	return cfg
 }
 
 func getEnv(key, defaultValue string) string {
@@ -108,6 +193,125 @@ func getEnvCSV(key string, defaultValue []string) []string {
 	return out
 }
 
+func getEnvIntCSV(key string, defaultValue []int) []int {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+	out := make([]int, 0, 8)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		num, err := strconv.Atoi(part)
+		if err != nil || num <= 0 {
+			log.Fatalf("Environment variable %s must be a list of positive integers (got %q)", key, value)
+		}
+		out = append(out, num)
+	}
+	if len(out) == 0 {
+		return defaultValue
+	}
+	return out
+}
+
+// RenditionSpec 缩略图规格：Height 为 0 时按宽度等比缩放；Crop 为 true 时居中裁剪为 Width x Height
+type RenditionSpec struct {
+	Name   string
+	Width  int
+	Height int
+	Crop   bool
+}
+
+// getEnvRenditions 解析形如 "sq200:200x200:crop,w400:400,w1280:1280" 的规格列表
+func getEnvRenditions(key string, defaultValue []RenditionSpec) []RenditionSpec {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+
+	out := make([]RenditionSpec, 0, 4)
+	seen := make(map[string]struct{})
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		spec, err := parseRenditionSpec(item)
+		if err != nil {
+			log.Fatalf("Environment variable %s: %v", key, err)
+		}
+		if _, ok := seen[spec.Name]; ok {
+			log.Fatalf("Environment variable %s: duplicate rendition %q", key, spec.Name)
+		}
+		seen[spec.Name] = struct{}{}
+		out = append(out, spec)
+	}
+	if len(out) == 0 {
+		return defaultValue
+	}
+	return out
+}
+
+func parseRenditionSpec(item string) (RenditionSpec, error) {
+	parts := strings.Split(item, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return RenditionSpec{}, fmt.Errorf("invalid rendition %q (want name:W[xH][:crop])", item)
+	}
+
+	spec := RenditionSpec{Name: strings.TrimSpace(parts[0])}
+	if spec.Name == "" || strings.ContainsAny(spec.Name, "/\\ ") {
+		return RenditionSpec{}, fmt.Errorf("invalid rendition name in %q", item)
+	}
+
+	size := strings.ToLower(strings.TrimSpace(parts[1]))
+	w, h, hasHeight := strings.Cut(size, "x")
+	width, err := strconv.Atoi(w)
+	if err != nil || width <= 0 {
+		return RenditionSpec{}, fmt.Errorf("invalid rendition width in %q", item)
+	}
+	spec.Width = width
+	if hasHeight {
+		height, err := strconv.Atoi(h)
+		if err != nil || height <= 0 {
+			return RenditionSpec{}, fmt.Errorf("invalid rendition height in %q", item)
+		}
+		spec.Height = height
+	}
+
+	if len(parts) == 3 {
+		if strings.ToLower(strings.TrimSpace(parts[2])) != "crop" {
+			return RenditionSpec{}, fmt.Errorf("unknown rendition mode in %q", item)
+		}
+		if spec.Height == 0 {
+			spec.Height = spec.Width
+		}
+		spec.Crop = true
+	}
+	return spec, nil
+}
+
+func defaultThumbnailRenditions() []RenditionSpec {
+	return []RenditionSpec{
+		{Name: "sq200", Width: 200, Height: 200, Crop: true},
+		{Name: "w400", Width: 400},
+		{Name: "w1280", Width: 1280},
+		{Name: "w2048", Width: 2048},
+	}
+}
+
+func defaultUploadAllowedTypes() []string {
+	return []string{
+		"image/jpeg",
+		"image/png",
+		"image/gif",
+		"image/webp",
+		"image/bmp",
+		"image/tiff",
+	}
+}
+
 func defaultAITagCandidates() []string {
 	return []string{
 		"风景",
@@ -0,0 +1,271 @@
+// Package geocode 离线逆地理编码：在内置的城市表（GeoNames 风格，见 data/）中查找离坐标最近的城市，
+// 得到国家、省/州与城市的中英文名称，不依赖外部服务。
+package geocode
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"math"
+	"photoms/internal/models"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed data/cities.tsv data/countries.tsv
+var dataFS embed.FS
+
+const (
+	// CityRadiusKm 最近的城市在该距离内时定位到城市
+	CityRadiusKm = 50
+	// RegionRadiusKm 最近的城市在该距离内时只定位到其所在的省/州与国家，更远（如海上）则不定位
+	RegionRadiusKm = 200
+)
+
+const earthRadiusKm = 6371.0
+
+// kmPerDegree 经线上 1° 的长度
+const kmPerDegree = earthRadiusKm * math.Pi / 180
+
+// cellDegrees 网格索引的格子大小（经纬度）
+const cellDegrees = 1
+
+// City 城市表中的一条记录
+type City struct {
+	CountryCode string
+	Province    string
+	ProvinceZh  string
+	Name        string
+	NameZh      string
+	Latitude    float64
+	Longitude   float64
+}
+
+// Country 国家/地区名称
+type Country struct {
+	Code   string
+	Name   string
+	NameZh string
+}
+
+type cell struct{ lat, lng int }
+
+// Gazetteer 城市表及其网格索引，只读，可并发使用
+type Gazetteer struct {
+	cities    []City
+	countries map[string]Country
+	cells     map[cell][]int
+}
+
+var (
+	defaultOnce      sync.Once
+	defaultGazetteer *Gazetteer
+)
+
+// Default 返回内置城市表，首次调用时加载
+func Default() *Gazetteer {
+	defaultOnce.Do(func() {
+		cities, err := dataFS.Open("data/cities.tsv")
+		if err != nil {
+			panic(err)
+		}
+		defer cities.Close()
+		countries, err := dataFS.Open("data/countries.tsv")
+		if err != nil {
+			panic(err)
+		}
+		defer countries.Close()
+		// 内置数据随程序编译，解析失败说明数据文件本身有误
+		if defaultGazetteer, err = Load(cities, countries); err != nil {
+			panic(fmt.Sprintf("geocode: invalid embedded data: %v", err))
+		}
+	})
+	return defaultGazetteer
+}
+
+// Load 读取制表符分隔的城市表与国家表，# 开头的行为注释。
+// 城市表各列：country_code, province, province_zh, name, name_zh, latitude, longitude；
+// 国家表各列：country_code, name, name_zh
+func Load(cities, countries io.Reader) (*Gazetteer, error) {
+	g := &Gazetteer{countries: make(map[string]Country), cells: make(map[cell][]int)}
+
+	err := readTSV(countries, 3, func(fields []string) error {
+		c := Country{Code: strings.ToUpper(fields[0]), Name: fields[1], NameZh: fields[2]}
+		if c.Code == "" || c.Name == "" {
+			return fmt.Errorf("missing country code or name")
+		}
+		g.countries[c.Code] = c
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("countries: %w", err)
+	}
+
+	err = readTSV(cities, 7, func(fields []string) error {
+		c := City{
+			CountryCode: strings.ToUpper(fields[0]),
+			Province:    fields[1],
+			ProvinceZh:  fields[2],
+			Name:        fields[3],
+			NameZh:      fields[4],
+		}
+		if _, ok := g.countries[c.CountryCode]; !ok {
+			return fmt.Errorf("unknown country code %q", c.CountryCode)
+		}
+		if c.Name == "" {
+			return fmt.Errorf("missing city name")
+		}
+		lat, err := strconv.ParseFloat(fields[5], 64)
+		if err != nil || lat < -90 || lat > 90 {
+			return fmt.Errorf("invalid latitude %q", fields[5])
+		}
+		lng, err := strconv.ParseFloat(fields[6], 64)
+		if err != nil || lng < -180 || lng > 180 {
+			return fmt.Errorf("invalid longitude %q", fields[6])
+		}
+		c.Latitude, c.Longitude = lat, lng
+
+		key := cellOf(lat, lng)
+		g.cells[key] = append(g.cells[key], len(g.cities))
+		g.cities = append(g.cities, c)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cities: %w", err)
+	}
+	return g, nil
+}
+
+func readTSV(r io.Reader, columns int, fn func(fields []string) error) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) != columns {
+			return fmt.Errorf("line %d: expected %d columns, got %d", line, columns, len(fields))
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if err := fn(fields); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func cellOf(lat, lng float64) cell {
+	return cell{lat: int(math.Floor(lat / cellDegrees)), lng: wrapCell(int(math.Floor(lng / cellDegrees)))}
+}
+
+// wrapCell 将经度方向的格子编号折回 [-180, 180) 对应的范围，用于跨越 180° 经线的查找
+func wrapCell(i int) int {
+	n := 360 / cellDegrees
+	return ((i+n/2)%n+n)%n - n/2
+}
+
+// Nearest 返回距 (lat, lng) 不超过 maxKm 的最近城市及其距离（公里）
+func (g *Gazetteer) Nearest(lat, lng, maxKm float64) (*City, float64, bool) {
+	// 只扫描以该点为中心、边长覆盖 maxKm 的网格窗口
+	latSpan := maxKm / kmPerDegree
+	minLat := math.Max(lat-latSpan, -90)
+	maxLat := math.Min(lat+latSpan, 90)
+	// 窗口内纬度绝对值最大处经度方向最窄，按该处换算经度范围
+	cos := math.Cos(math.Max(math.Abs(minLat), math.Abs(maxLat)) * math.Pi / 180)
+	lngSpan := 180.0
+	if cos > 0 {
+		lngSpan = math.Min(latSpan/cos, 180)
+	}
+
+	lngFrom := int(math.Floor((lng - lngSpan) / cellDegrees))
+	lngTo := int(math.Floor((lng + lngSpan) / cellDegrees))
+	if lngTo-lngFrom+1 >= 360/cellDegrees {
+		lngFrom, lngTo = -180/cellDegrees, 180/cellDegrees-1
+	}
+
+	var best *City
+	bestKm := maxKm
+	for y := int(math.Floor(minLat / cellDegrees)); y <= int(math.Floor(maxLat/cellDegrees)); y++ {
+		for x := lngFrom; x <= lngTo; x++ {
+			for _, i := range g.cells[cell{lat: y, lng: wrapCell(x)}] {
+				c := &g.cities[i]
+				if d := haversineKm(lat, lng, c.Latitude, c.Longitude); d <= bestKm {
+					best, bestKm = c, d
+				}
+			}
+		}
+	}
+	return best, bestKm, best != nil
+}
+
+// Lookup 将 GPS 坐标解析为地点：CityRadiusKm 内有城市时包含城市，RegionRadiusKm 内只包含省/州与国家；
+// 坐标无效或附近没有收录的城市时返回 nil
+func (g *Gazetteer) Lookup(gps *models.GPSInfo) *models.Place {
+	if models.NewGeoPoint(gps) == nil {
+		return nil
+	}
+	city, km, ok := g.Nearest(gps.Latitude, gps.Longitude, RegionRadiusKm)
+	if !ok {
+		return nil
+	}
+
+	country := g.countries[city.CountryCode]
+	place := &models.Place{
+		CountryCode: country.Code,
+		Country:     zhOr(country.NameZh, country.Name),
+		CountryEn:   country.Name,
+		Province:    zhOr(city.ProvinceZh, city.Province),
+		ProvinceEn:  city.Province,
+	}
+	if km <= CityRadiusKm {
+		place.City = zhOr(city.NameZh, city.Name)
+		place.CityEn = city.Name
+	}
+	return place
+}
+
+// Lookup 使用内置城市表解析 GPS 坐标，见 Gazetteer.Lookup
+func Lookup(gps *models.GPSInfo) *models.Place {
+	return Default().Lookup(gps)
+}
+
+// Names 返回地点的中英文名称（城市、省/州、国家依次排列），已去重，可作为标签
+func Names(place *models.Place) []string {
+	if place == nil {
+		return nil
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range []string{place.City, place.CityEn, place.Province, place.ProvinceEn, place.Country, place.CountryEn} {
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+func zhOr(zh, en string) string {
+	if zh != "" {
+		return zh
+	}
+	return en
+}
+
+func haversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	const rad = math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLng := (lng2 - lng1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"photoms/pkg/config"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Backend 将对象保存在 S3 兼容的对象存储（AWS S3 / MinIO / 阿里云 OSS 等）
+type S3Backend struct {
+	client    *minio.Client
+	bucket    string
+	prefix    string
+	publicURL string
+}
+
+func NewS3Backend(cfg *config.Config) (*S3Backend, error) {
+	endpoint := strings.TrimSpace(cfg.S3Endpoint)
+	if endpoint == "" {
+		return nil, fmt.Errorf("S3_ENDPOINT is required when STORAGE_DRIVER=s3")
+	}
+	if strings.TrimSpace(cfg.S3Bucket) == "" {
+		return nil, fmt.Errorf("S3_BUCKET is required when STORAGE_DRIVER=s3")
+	}
+
+	// 允许直接填写 http(s):// 前缀
+	useSSL := cfg.S3UseSSL
+	if strings.HasPrefix(endpoint, "https://") {
+		endpoint = strings.TrimPrefix(endpoint, "https://")
+		useSSL = true
+	} else if strings.HasPrefix(endpoint, "http://") {
+		endpoint = strings.TrimPrefix(endpoint, "http://")
+		useSSL = false
+	}
+	endpoint = strings.TrimRight(endpoint, "/")
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.S3AccessKey, cfg.S3SecretKey, ""),
+		Secure: useSSL,
+		Region: cfg.S3Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	exists, err := client.BucketExists(ctx, cfg.S3Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bucket %s: %w", cfg.S3Bucket, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.S3Bucket, minio.MakeBucketOptions{Region: cfg.S3Region}); err != nil {
+			return nil, fmt.Errorf("failed to create bucket %s: %w", cfg.S3Bucket, err)
+		}
+	}
+
+	publicURL := strings.TrimRight(strings.TrimSpace(cfg.S3PublicURL), "/")
+	if publicURL == "" {
+		scheme := "http"
+		if useSSL {
+			scheme = "https"
+		}
+		publicURL = fmt.Sprintf("%s://%s/%s", scheme, endpoint, cfg.S3Bucket)
+	}
+
+	prefix := strings.Trim(strings.TrimSpace(cfg.S3Prefix), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	return &S3Backend{
+		client:    client,
+		bucket:    cfg.S3Bucket,
+		prefix:    prefix,
+		publicURL: publicURL,
+	}, nil
+}
+
+func (b *S3Backend) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	objectKey, err := b.objectKey(key)
+	if err != nil {
+		return err
+	}
+	if size <= 0 {
+		size = -1
+	}
+	_, err = b.client.PutObject(ctx, b.bucket, objectKey, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+func (b *S3Backend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	objectKey, err := b.objectKey(key)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := b.client.GetObject(ctx, b.bucket, objectKey, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, translateS3Error(err)
+	}
+	// GetObject 是惰性的，需要 Stat 一次才能知道对象是否存在
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, translateS3Error(err)
+	}
+	return obj, nil
+}
+
+func (b *S3Backend) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	objectKey, err := b.objectKey(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := b.client.StatObject(ctx, b.bucket, objectKey, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, translateS3Error(err)
+	}
+	return &ObjectInfo{
+		Key:         strings.TrimPrefix(info.Key, b.prefix),
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		ModTime:     info.LastModified,
+	}, nil
+}
+
+func (b *S3Backend) Delete(ctx context.Context, key string) error {
+	objectKey, err := b.objectKey(key)
+	if err != nil {
+		return err
+	}
+	// S3 删除不存在的对象不会报错，这里与本地实现保持一致
+	if _, err := b.client.StatObject(ctx, b.bucket, objectKey, minio.StatObjectOptions{}); err != nil {
+		return translateS3Error(err)
+	}
+	return b.client.RemoveObject(ctx, b.bucket, objectKey, minio.RemoveObjectOptions{})
+}
+
+func (b *S3Backend) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var out []ObjectInfo
+	for obj := range b.client.ListObjects(ctx, b.bucket, minio.ListObjectsOptions{
+		Prefix:    b.prefix + prefix,
+		Recursive: true,
+	}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		out = append(out, ObjectInfo{
+			Key:         strings.TrimPrefix(obj.Key, b.prefix),
+			Size:        obj.Size,
+			ContentType: obj.ContentType,
+			ModTime:     obj.LastModified,
+		})
+	}
+	return out, nil
+}
+
+func (b *S3Backend) URL(key string) string {
+	objectKey, err := b.objectKey(key)
+	if err != nil {
+		return ""
+	}
+	return b.publicURL + "/" + objectKey
+}
+
+func (b *S3Backend) objectKey(key string) (string, error) {
+	cleaned, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return b.prefix + cleaned, nil
+}
+
+func translateS3Error(err error) error {
+	resp := minio.ToErrorResponse(err)
+	if resp.StatusCode == http.StatusNotFound || resp.Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"photoms/pkg/config"
+)
+
+var (
+	ErrNotFound   = errors.New("storage: object not found")
+	ErrInvalidKey = errors.New("storage: invalid object key")
+)
+
+// ObjectInfo 描述存储后端中的一个对象
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Backend 抽象图片文件的存储位置（本地磁盘 / S3 兼容对象存储）。
+// key 为相对路径（如 "1700000000_abcd1234.jpg"），不包含 UploadDir 或 bucket 前缀。
+type Backend interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// URL 返回前端可访问该对象的地址
+	URL(key string) string
+}
+
+func NewBackend(cfg *config.Config) (Backend, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.StorageDriver)) {
+	case "", "local":
+		return NewLocalBackend(cfg.UploadDir, "/uploads")
+	case "s3", "minio":
+		return NewS3Backend(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported STORAGE_DRIVER=%q", cfg.StorageDriver)
+	}
+}
+
+// CleanKey 规范化对象 key，拒绝绝对路径与 ".." 越界
+func CleanKey(key string) (string, error) {
+	key = strings.TrimSpace(strings.ReplaceAll(key, "\\", "/"))
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean("/" + key)
+	cleaned = strings.TrimPrefix(cleaned, "/")
+	if cleaned == "" || cleaned == "." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
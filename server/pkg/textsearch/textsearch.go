@@ -0,0 +1,234 @@
+// Package textsearch 为图片的标题、描述与标签建立检索词，并生成高亮片段。
+//
+// 中文等 CJK 文本没有空格分词，这里采用二元切分（bigram）：连续的 CJK 字符切成相邻两字的词，
+// 同时为索引保留单字，这样单字查询与任意长度的词组查询都能命中；拉丁字母与数字按单词切分并转小写。
+package textsearch
+
+import (
+	"html"
+	"photoms/internal/models"
+	"strings"
+	"unicode"
+)
+
+// 各字段命中时的权重（标题 > 标签 > 描述）
+const (
+	TitleWeight       = 3
+	TagWeight         = 2
+	DescriptionWeight = 1
+)
+
+// snippetRunes 描述高亮片段的最大长度（字符）
+const snippetRunes = 80
+
+const (
+	highlightStart = "<em>"
+	highlightEnd   = "</em>"
+)
+
+// IndexTerms 返回文本用于建立索引的检索词（CJK 单字 + 二元词、拉丁单词），已去重
+func IndexTerms(text string) []string {
+	return tokenize(text, true)
+}
+
+// QueryTerms 返回查询文本的检索词：CJK 片段只取二元词（单字片段取单字），已去重
+func QueryTerms(text string) []string {
+	return tokenize(text, false)
+}
+
+// BuildIndex 根据图片当前的标题、描述与标签生成检索索引
+func BuildIndex(title, description string, tags []models.Tag) *models.SearchIndex {
+	idx := &models.SearchIndex{
+		Title:       IndexTerms(title),
+		Description: IndexTerms(description),
+	}
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	idx.Tags = IndexTerms(strings.Join(names, "\n"))
+	idx.Terms = union(idx.Title, idx.Description, idx.Tags)
+	return idx
+}
+
+// Highlight 用 <em></em> 标出 photo 中与查询匹配的部分（其余内容已做 HTML 转义），无匹配时返回 nil。
+// 描述过长时只返回第一个匹配附近的片段。
+func Highlight(photo *models.Photo, query string) *models.SearchHighlights {
+	terms := QueryTerms(query)
+	if photo == nil || len(terms) == 0 {
+		return nil
+	}
+
+	out := &models.SearchHighlights{}
+	found := false
+	if s, ok := highlight(photo.Title, terms, 0); ok {
+		out.Title = s
+		found = true
+	}
+	if s, ok := highlight(photo.Description, terms, snippetRunes); ok {
+		out.Description = s
+		found = true
+	}
+	for _, tag := range photo.Tags {
+		if s, ok := highlight(tag.Name, terms, 0); ok {
+			out.Tags = append(out.Tags, s)
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return out
+}
+
+func tokenize(text string, forIndex bool) []string {
+	var terms []string
+	seen := make(map[string]struct{})
+	add := func(term string) {
+		if _, ok := seen[term]; ok {
+			return
+		}
+		seen[term] = struct{}{}
+		terms = append(terms, term)
+	}
+
+	var word, cjk []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			add(string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 || (forIndex && len(cjk) > 1) {
+			for _, r := range cjk {
+				add(string(r))
+			}
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			add(string(cjk[i : i+2]))
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return terms
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// highlight 标出 text 中所有检索词的出现位置（重叠/相邻的合并为一段）；maxRunes > 0 时截取片段
+func highlight(text string, terms []string, maxRunes int) (string, bool) {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		// 极少数字符转小写后长度变化，退化为逐字符转换
+		lower = make([]rune, len(runes))
+		for i, r := range runes {
+			lower[i] = unicode.ToLower(r)
+		}
+	}
+
+	marked := make([]bool, len(runes))
+	first := -1
+	for _, term := range terms {
+		t := []rune(term)
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if !hasPrefix(lower[i:], t) || !wordBoundary(lower, i, len(t)) {
+				continue
+			}
+			for j := i; j < i+len(t); j++ {
+				marked[j] = true
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+		}
+	}
+	if first < 0 {
+		return "", false
+	}
+
+	start, end := 0, len(runes)
+	if maxRunes > 0 && len(runes) > maxRunes {
+		start = max(0, first-maxRunes/4)
+		end = min(len(runes), start+maxRunes)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && marked[j] == marked[i] {
+			j++
+		}
+		segment := html.EscapeString(string(runes[i:j]))
+		if marked[i] {
+			b.WriteString(highlightStart + segment + highlightEnd)
+		} else {
+			b.WriteString(segment)
+		}
+		i = j
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
+
+func hasPrefix(s, prefix []rune) bool {
+	for i, r := range prefix {
+		if s[i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// wordBoundary 拉丁单词只在完整单词处匹配（与索引的切分方式一致），CJK 不要求边界
+func wordBoundary(s []rune, i, n int) bool {
+	if isCJK(s[i]) {
+		return true
+	}
+	isWord := func(r rune) bool { return !isCJK(r) && (unicode.IsLetter(r) || unicode.IsDigit(r)) }
+	if i > 0 && isWord(s[i-1]) {
+		return false
+	}
+	if i+n < len(s) && isWord(s[i+n]) {
+		return false
+	}
+	return true
+}
+
+func union(lists ...[]string) []string {
+	var out []string
+	seen := make(map[string]struct{})
+	for _, list := range lists {
+		for _, term := range list {
+			if _, ok := seen[term]; ok {
+				continue
+			}
+			seen[term] = struct{}{}
+			out = append(out, term)
+		}
+	}
+	return out
+}
@@ -4,8 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"image"
+	"math"
 	"os"
 	"photoms/internal/models"
+	"strconv"
+	"strings"
 
 	"github.com/disintegration/imaging"
 	"github.com/rwcarlsen/goexif/exif"
@@ -61,6 +64,9 @@ func ExtractExif(filePath string) (*models.ExifInfo, error) {
 	// 提取快门速度
 	if shutter, err := x.Get(exif.ExposureTime); err == nil {
 		if num, denom, err := shutter.Rat2(0); err == nil {
+			if denom != 0 {
+				exifInfo.ExposureTime = float64(num) / float64(denom)
+			}
 			if num == 1 {
 				exifInfo.ShutterSpeed = fmt.Sprintf("1/%d", denom)
 			} else {
@@ -76,6 +82,13 @@ func ExtractExif(filePath string) (*models.ExifInfo, error) {
 		}
 	}
 
+	// 提取方向（手机竖拍照片的像素通常是横向存储，需按此旋转）
+	if orientation, err := x.Get(exif.Orientation); err == nil {
+		if val, err := orientation.Int(0); err == nil && val >= 1 && val <= 8 {
+			exifInfo.Orientation = val
+		}
+	}
+
 	// 提取GPS信息
 	lat, lon, err := x.LatLong()
 	if err == nil {
@@ -85,19 +98,26 @@ func ExtractExif(filePath string) (*models.ExifInfo, error) {
 		}
 	}
 
-	// 提取拍摄时间
-	if dt, err := x.DateTime(); err == nil {
+	// 提取拍摄时间（尽量确定时区偏移，见 exifDateTime）
+	if dt, offset, err := exifDateTime(x); err == nil {
 		takenAt := primitive.NewDateTimeFromTime(dt)
 		exifInfo.TakenAt = &takenAt
+		exifInfo.UTCOffset = offset
 	}
 
 	return exifInfo, nil
 }
 
+// OpenImage 解码图片并按 EXIF Orientation 旋转为正向。所有读取像素的地方（缩略图、编辑、渲染、AI 标注）
+// 都应使用它，保证坐标系与用户看到的一致。
+func OpenImage(path string) (image.Image, error) {
+	return imaging.Open(path, imaging.AutoOrientation(true))
+}
+
 // GenerateThumbnail 生成缩略图
 func GenerateThumbnail(srcPath, dstPath string, width int) error {
 	// 打开原图
-	src, err := imaging.Open(srcPath)
+	src, err := OpenImage(srcPath)
 	if err != nil {
 		return fmt.Errorf("failed to open image: %w", err)
 	}
@@ -114,9 +134,35 @@ func GenerateThumbnail(srcPath, dstPath string, width int) error {
 	return nil
 }
 
+// ResizeImage 按规格生成缩略图：crop 时居中裁剪填满 width x height；height 为 0 时按宽度等比缩放；
+// 否则等比缩放至不超过 width x height。原图小于目标尺寸时不放大。
+func ResizeImage(src image.Image, width, height int, crop bool) image.Image {
+	b := src.Bounds()
+	if crop {
+		if b.Dx() < width || b.Dy() < height {
+			// 原图不足目标尺寸：按较短边裁成同比例，避免放大
+			scale := min(float64(b.Dx())/float64(width), float64(b.Dy())/float64(height))
+			width = max(1, int(float64(width)*scale))
+			height = max(1, int(float64(height)*scale))
+		}
+		return imaging.Fill(src, width, height, imaging.Center, imaging.Lanczos)
+	}
+	if height <= 0 {
+		if b.Dx() <= width {
+			return src
+		}
+		return imaging.Resize(src, width, 0, imaging.Lanczos)
+	}
+	if b.Dx() <= width && b.Dy() <= height {
+		return src
+	}
+	return imaging.Fit(src, width, height, imaging.Lanczos)
+}
+
 // EditImage 处理图片：裁剪并调整色调
 func EditImage(srcPath, dstPath string, cropX, cropY, cropW, cropH int, brightness, contrast, saturation float64) error {
-	src, err := imaging.Open(srcPath)
+	// 裁剪坐标以正向显示的图片为准
+	src, err := OpenImage(srcPath)
 	if err != nil {
 		if errors.Is(err, image.ErrFormat) {
 			return fmt.Errorf("unsupported image format (supported: jpg/png/gif/bmp/tiff/webp): %w", err)
@@ -167,3 +213,33 @@ func EditImage(srcPath, dstPath string, cropX, cropY, cropW, cropH int, brightne
 	}
 	return nil
 }
+
+// ParseFinite 解析有限的浮点数（拒绝 Inf、NaN，以免进入查询条件）
+func ParseFinite(value string) (float64, bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsInf(n, 0) || math.IsNaN(n) {
+		return 0, false
+	}
+	return n, true
+}
+
+// ParseShutterSpeed 解析快门速度（"1/250"、"0.5"、"2"，可带 s 后缀）为秒
+func ParseShutterSpeed(value string) (float64, bool) {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "s")
+	if value == "" {
+		return 0, false
+	}
+	if num, denom, ok := strings.Cut(value, "/"); ok {
+		n, ok1 := ParseFinite(strings.TrimSpace(num))
+		d, ok2 := ParseFinite(strings.TrimSpace(denom))
+		if !ok1 || !ok2 || d <= 0 || n < 0 {
+			return 0, false
+		}
+		return n / d, true
+	}
+	seconds, ok := ParseFinite(value)
+	if !ok || seconds < 0 {
+		return 0, false
+	}
+	return seconds, true
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMediaSignatureInvalid = errors.New("invalid media signature")
+	ErrMediaURLExpired       = errors.New("media url expired")
+)
+
+// MediaPathPrefix 受保护媒体文件的访问前缀
+const MediaPathPrefix = "/media/"
+
+// MediaKeyFromPath 从 Path/ThumbPath（如 /uploads/xxx.jpg、对象存储 URL）中取出存储 key
+func MediaKeyFromPath(webPath string) string {
+	p := strings.TrimSpace(webPath)
+	if u, err := url.Parse(p); err == nil {
+		p = u.Path
+	}
+	base := path.Base(p)
+	if base == "" || base == "." || base == "/" {
+		return ""
+	}
+	return base
+}
+
+// SignMediaPath 为存储 key 生成带过期时间与 HMAC 签名的访问路径：/media/<key>?exp=<unix>&sig=<hmac>
+func SignMediaPath(key, secret string, expiresAt time.Time) string {
+	if key == "" {
+		return ""
+	}
+	exp := strconv.FormatInt(expiresAt.Unix(), 10)
+	q := url.Values{}
+	q.Set("exp", exp)
+	q.Set("sig", mediaSignature(key, exp, secret))
+	return MediaPathPrefix + url.PathEscape(key) + "?" + q.Encode()
+}
+
+// VerifyMediaSignature 校验签名与有效期
+func VerifyMediaSignature(key, exp, sig, secret string) error {
+	if key == "" || exp == "" || sig == "" {
+		return ErrMediaSignatureInvalid
+	}
+	expected := mediaSignature(key, exp, secret)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return ErrMediaSignatureInvalid
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return ErrMediaSignatureInvalid
+	}
+	if time.Now().Unix() > unix {
+		return ErrMediaURLExpired
+	}
+	return nil
+}
+
+// MediaExpiry 计算签名过期时间。按 ttl/2 对齐，使同一时间窗口内生成的 URL 相同，便于浏览器缓存；
+// 保证返回的 URL 至少还有 ttl/2 的有效期。
+func MediaExpiry(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	window := ttl / 2
+	if window <= 0 {
+		return now.Add(ttl)
+	}
+	return now.Truncate(window).Add(ttl)
+}
+
+func mediaSignature(key, exp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(key))
+	mac.Write([]byte{'\n'})
+	mac.Write([]byte(exp))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMediaKeyFromPath(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"/uploads/abc.jpg", "abc.jpg"},
+		{"  /uploads/thumbs/abc_thumb.jpg ", "abc_thumb.jpg"},
+		{"https://bucket.example.com/photos/abc.jpg?X-Amz-Signature=1", "abc.jpg"},
+		{"abc.jpg", "abc.jpg"},
+		{"", ""},
+		{"/", ""},
+	}
+	for _, tt := range tests {
+		if got := MediaKeyFromPath(tt.in); got != tt.want {
+			t.Errorf("MediaKeyFromPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// parseSigned 拆出签名路径中的 key、exp 与 sig
+func parseSigned(t *testing.T, signed string) (key, exp, sig string) {
+	t.Helper()
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("parse %q: %v", signed, err)
+	}
+	if !strings.HasPrefix(u.Path, MediaPathPrefix) {
+		t.Fatalf("signed path %q lacks prefix %q", signed, MediaPathPrefix)
+	}
+	return strings.TrimPrefix(u.Path, MediaPathPrefix), u.Query().Get("exp"), u.Query().Get("sig")
+}
+
+func TestSignAndVerifyMediaPath(t *testing.T) {
+	const secret = "s3cret"
+	future := time.Now().Add(time.Hour)
+
+	if got := SignMediaPath("", secret, future); got != "" {
+		t.Errorf("SignMediaPath(empty key) = %q, want empty", got)
+	}
+
+	key, exp, sig := parseSigned(t, SignMediaPath("a b/c.jpg", secret, future))
+	if key != "a b/c.jpg" {
+		t.Errorf("key = %q, want it unescaped back to the original", key)
+	}
+
+	tampered := []byte(sig)
+	tampered[len(tampered)-1] ^= 1
+	tests := []struct {
+		name          string
+		key, exp, sig string
+		secret        string
+		want          error
+	}{
+		{"valid", key, exp, sig, secret, nil},
+		{"wrong secret", key, exp, sig, "other", ErrMediaSignatureInvalid},
+		{"other key", "d.jpg", exp, sig, secret, ErrMediaSignatureInvalid},
+		{"extended expiry", key, exp + "0", sig, secret, ErrMediaSignatureInvalid},
+		{"tampered signature", key, exp, string(tampered), secret, ErrMediaSignatureInvalid},
+		{"missing exp", key, "", sig, secret, ErrMediaSignatureInvalid},
+		{"missing sig", key, exp, "", secret, ErrMediaSignatureInvalid},
+	}
+	for _, tt := range tests {
+		if err := VerifyMediaSignature(tt.key, tt.exp, tt.sig, tt.secret); !errors.Is(err, tt.want) {
+			t.Errorf("%s: VerifyMediaSignature = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	key, exp, sig = parseSigned(t, SignMediaPath("c.jpg", secret, time.Now().Add(-time.Minute)))
+	if err := VerifyMediaSignature(key, exp, sig, secret); !errors.Is(err, ErrMediaURLExpired) {
+		t.Errorf("expired: VerifyMediaSignature = %v, want ErrMediaURLExpired", err)
+	}
+}
+
+func TestMediaExpiry(t *testing.T) {
+	ttl := time.Hour
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{base, base.Add(ttl)},
+		{base.Add(29 * time.Minute), base.Add(ttl)},
+		{base.Add(30 * time.Minute), base.Add(30*time.Minute + ttl)},
+		{base.Add(59 * time.Minute), base.Add(30*time.Minute + ttl)},
+	}
+	for _, tt := range tests {
+		got := MediaExpiry(tt.now, ttl)
+		if !got.Equal(tt.want) {
+			t.Errorf("MediaExpiry(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+		// 同一时间窗口内生成的 URL 相同，且至少还有 ttl/2 的有效期
+		if remaining := got.Sub(tt.now); remaining < ttl/2 || remaining > ttl {
+			t.Errorf("MediaExpiry(%v) leaves %v, want between %v and %v", tt.now, remaining, ttl/2, ttl)
+		}
+	}
+
+	if got := MediaExpiry(base, 0); !got.Equal(base.Add(time.Hour)) {
+		t.Errorf("MediaExpiry with zero ttl = %v, want default of one hour", got)
+	}
+}
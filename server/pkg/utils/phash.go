@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"math/bits"
+	"strconv"
+
+	"github.com/disintegration/imaging"
+)
+
+// PerceptualHash 计算 64 位差值哈希（dHash）：缩成 9x8 灰度图，逐行比较相邻像素亮度。
+// 重新保存、缩放、轻微调色后的同一张照片哈希值相近，可用汉明距离判断是否近似重复。
+func PerceptualHash(img image.Image) string {
+	small := imaging.Resize(imaging.Grayscale(img), 9, 8, imaging.Box)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			left := small.Pix[y*small.Stride+x*4]
+			right := small.Pix[y*small.Stride+(x+1)*4]
+			hash <<= 1
+			if left < right {
+				hash |= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%016x", hash)
+}
+
+// ParsePerceptualHash 解析 PerceptualHash 生成的十六进制字符串
+func ParsePerceptualHash(s string) (uint64, bool) {
+	if len(s) != 16 {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// HammingDistance 两个哈希之间不同的位数
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// HashBands 将 64 位哈希按位均分为 n 段（1-64），返回各段的值。
+// 汉明距离不超过 n-1 的两个哈希至少有一段完全相同（抽屉原理），可按段分桶查找近似哈希，避免两两比较
+func HashBands(hash uint64, n int) []uint64 {
+	n = max(1, min(n, 64))
+	bands := make([]uint64, n)
+	for b := range bands {
+		start, end := b*64/n, (b+1)*64/n
+		bands[b] = (hash >> start) & (1<<(end-start) - 1)
+	}
+	return bands
+}
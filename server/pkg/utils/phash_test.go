@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParsePerceptualHash(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint64
+		ok   bool
+	}{
+		{"0000000000000000", 0, true},
+		{"ffffffffffffffff", ^uint64(0), true},
+		{"8000000000000001", 1<<63 | 1, true},
+		{"", 0, false},
+		{"fff", 0, false},
+		{"zzzzzzzzzzzzzzzz", 0, false},
+		{"0ffffffffffffffff", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParsePerceptualHash(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParsePerceptualHash(%q) = %x, %v, want %x, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0, ^uint64(0), 64},
+		{0b1011, 0b0001, 2},
+		{1 << 63, 1, 2},
+	}
+	for _, tt := range tests {
+		if got := HammingDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("HammingDistance(%x, %x) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestHashBands(t *testing.T) {
+	tests := []struct {
+		hash uint64
+		n    int
+		want []uint64
+	}{
+		{0xdeadbeefcafebabe, 1, []uint64{0xdeadbeefcafebabe}},
+		{0xdeadbeefcafebabe, 0, []uint64{0xdeadbeefcafebabe}},
+		{0xdeadbeefcafebabe, 2, []uint64{0xcafebabe, 0xdeadbeef}},
+		{0xdeadbeefcafebabe, 4, []uint64{0xbabe, 0xcafe, 0xbeef, 0xdead}},
+	}
+	for _, tt := range tests {
+		got := HashBands(tt.hash, tt.n)
+		if len(got) != len(tt.want) {
+			t.Fatalf("HashBands(%x, %d) = %x, want %x", tt.hash, tt.n, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("HashBands(%x, %d) = %x, want %x", tt.hash, tt.n, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+// 距离不超过 n-1 的哈希至少有一段相同
+func TestHashBandsPigeonhole(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for threshold := 0; threshold <= 16; threshold++ {
+		for trial := 0; trial < 200; trial++ {
+			a := rng.Uint64()
+			b := a
+			for _, bit := range rng.Perm(64)[:threshold] {
+				b ^= 1 << bit
+			}
+			bandsA, bandsB := HashBands(a, threshold+1), HashBands(b, threshold+1)
+			shared := false
+			for i := range bandsA {
+				shared = shared || bandsA[i] == bandsB[i]
+			}
+			if !shared {
+				t.Fatalf("threshold %d: %x and %x share no band", threshold, a, b)
+			}
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/disintegration/imaging"
+)
+
+// RenderImage 按请求尺寸缩放：只给宽或高时等比缩放；同时给出宽高时 fit=cover 居中裁剪填满，
+// fit=contain 等比缩放至不超过该尺寸。与 ResizeImage 一样不放大小图。
+func RenderImage(src image.Image, width, height int, fit string) image.Image {
+	if width <= 0 && height <= 0 {
+		return src
+	}
+	if width <= 0 {
+		if src.Bounds().Dy() <= height {
+			return src
+		}
+		return imaging.Resize(src, 0, height, imaging.Lanczos)
+	}
+	return ResizeImage(src, width, height, fit == "cover" && height > 0)
+}
+
+// EncodeImage 按格式编码图片：jpeg/png 使用标准库，webp 使用纯 Go 的无损编码器（quality 仅对 jpeg 生效）
+func EncodeImage(w io.Writer, img image.Image, format string, quality int) error {
+	switch format {
+	case "jpeg", "jpg":
+		return imaging.Encode(w, img, imaging.JPEG, imaging.JPEGQuality(quality))
+	case "png":
+		return imaging.Encode(w, img, imaging.PNG)
+	case "webp":
+		return nativewebp.Encode(w, img, nil)
+	default:
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+}
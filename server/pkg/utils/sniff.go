@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"os"
+)
+
+// SniffImageType 根据文件头的 magic bytes 判断图片类型，返回 MIME 类型与规范扩展名。
+// 无法识别时返回空字符串。
+func SniffImageType(header []byte) (mimeType, ext string) {
+	switch {
+	case bytes.HasPrefix(header, []byte{0xFF, 0xD8, 0xFF}):
+		return "image/jpeg", ".jpg"
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png", ".png"
+	case bytes.HasPrefix(header, []byte("GIF87a")), bytes.HasPrefix(header, []byte("GIF89a")):
+		return "image/gif", ".gif"
+	case len(header) >= 12 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WEBP")):
+		return "image/webp", ".webp"
+	case bytes.HasPrefix(header, []byte("BM")) && len(header) >= 14:
+		return "image/bmp", ".bmp"
+	case bytes.HasPrefix(header, []byte("II*\x00")), bytes.HasPrefix(header, []byte("MM\x00*")):
+		return "image/tiff", ".tif"
+	case len(header) >= 12 && bytes.Equal(header[4:8], []byte("ftyp")) &&
+		(bytes.Equal(header[8:12], []byte("heic")) || bytes.Equal(header[8:12], []byte("heix")) || bytes.Equal(header[8:12], []byte("mif1"))):
+		return "image/heic", ".heic"
+	default:
+		return "", ""
+	}
+}
+
+// SniffImageFile 读取文件头判断图片类型
+func SniffImageFile(filePath string) (mimeType, ext string, err error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	header := make([]byte, 32)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", "", err
+	}
+	mimeType, ext = SniffImageType(header[:n])
+	return mimeType, ext, nil
+}
+
+// ImageDimensions 仅解析图片头部获取宽高，不解码像素（用于在 imaging.Open 之前拦截解压炸弹）
+func ImageDimensions(filePath string) (int, int, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to decode image header: %w", err)
+	}
+	return cfg.Width, cfg.Height, nil
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSniffImageType(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   []byte
+		wantMIME string
+		wantExt  string
+	}{
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0, 0, 0x10, 'J', 'F', 'I', 'F'}, "image/jpeg", ".jpg"},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), "image/png", ".png"},
+		{"gif87a", []byte("GIF87a\x01\x00"), "image/gif", ".gif"},
+		{"gif89a", []byte("GIF89a\x01\x00"), "image/gif", ".gif"},
+		{"webp", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), "image/webp", ".webp"},
+		{"bmp", []byte("BM\x36\x00\x00\x00\x00\x00\x00\x00\x36\x00\x00\x00"), "image/bmp", ".bmp"},
+		{"tiff little endian", []byte("II*\x00\x08\x00\x00\x00"), "image/tiff", ".tif"},
+		{"tiff big endian", []byte("MM\x00*\x00\x00\x00\x08"), "image/tiff", ".tif"},
+		{"heic", []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), "image/heic", ".heic"},
+		{"heif mif1", []byte("\x00\x00\x00\x18ftypmif1\x00\x00\x00\x00"), "image/heic", ".heic"},
+		{"mp4 is not an image", []byte("\x00\x00\x00\x18ftypisom\x00\x00\x00\x00"), "", ""},
+		{"riff without webp", []byte("RIFF\x24\x00\x00\x00WAVEfmt "), "", ""},
+		{"truncated bmp", []byte("BM\x36"), "", ""},
+		{"html", []byte("<!DOCTYPE html>"), "", ""},
+		{"empty", nil, "", ""},
+	}
+	for _, tt := range tests {
+		mimeType, ext := SniffImageType(tt.header)
+		if mimeType != tt.wantMIME || ext != tt.wantExt {
+			t.Errorf("%s: SniffImageType = %q, %q, want %q, %q", tt.name, mimeType, ext, tt.wantMIME, tt.wantExt)
+		}
+	}
+}
+
+func TestSniffImageFileAndDimensions(t *testing.T) {
+	dir := t.TempDir()
+
+	pngPath := filepath.Join(dir, "image.jpg") // 扩展名与内容不符时以内容为准
+	img := image.NewNRGBA(image.Rect(0, 0, 7, 3))
+	img.Set(1, 1, color.White)
+	f, err := os.Create(pngPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	mimeType, ext, err := SniffImageFile(pngPath)
+	if err != nil || mimeType != "image/png" || ext != ".png" {
+		t.Errorf("SniffImageFile(png) = %q, %q, %v", mimeType, ext, err)
+	}
+	w, h, err := ImageDimensions(pngPath)
+	if err != nil || w != 7 || h != 3 {
+		t.Errorf("ImageDimensions(png) = %d, %d, %v, want 7, 3", w, h, err)
+	}
+
+	// 短于文件头长度的文件不报错，只是无法识别
+	shortPath := filepath.Join(dir, "short")
+	if err := os.WriteFile(shortPath, []byte("GI"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if mimeType, _, err := SniffImageFile(shortPath); err != nil || mimeType != "" {
+		t.Errorf("SniffImageFile(short) = %q, %v", mimeType, err)
+	}
+	if _, _, err := ImageDimensions(shortPath); err == nil {
+		t.Error("ImageDimensions(short) should fail")
+	}
+
+	if _, _, err := SniffImageFile(filepath.Join(dir, "missing")); err == nil {
+		t.Error("SniffImageFile(missing) should fail")
+	}
+}
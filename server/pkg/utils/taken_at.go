@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"math"
+	"photoms/internal/models"
+	"strings"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const exifTimeLayout = "2006:01:02 15:04:05"
+
+// maxUTCOffset EXIF 时间与 GPS（UTC）时间之差超过该值时认为 GPS 时间不可信
+const maxUTCOffset = 14 * time.Hour
+
+// exifDateTime 解析拍摄时间。EXIF 的 DateTimeOriginal 是不带时区的相机本地时间：
+// 能确定时区偏移时（Canon TimeInfo，或与 GPS 的 UTC 时间比对）按该偏移换算并返回偏移分钟数，
+// 否则按服务器时区解释，偏移返回 nil。
+func exifDateTime(x *exif.Exif) (time.Time, *int, error) {
+	tag, err := x.Get(exif.DateTimeOriginal)
+	if err != nil {
+		if tag, err = x.Get(exif.DateTime); err != nil {
+			return time.Time{}, nil, err
+		}
+	}
+	value, err := tag.StringVal()
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	wall, err := time.Parse(exifTimeLayout, strings.TrimSpace(strings.TrimRight(value, "\x00")))
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	var offset *int
+	if tz, err := x.TimeZone(); err == nil && tz != nil {
+		_, seconds := wall.In(tz).Zone()
+		minutes := seconds / 60
+		offset = &minutes
+	} else if utc, ok := gpsTime(x); ok {
+		if diff := wall.Sub(utc); diff.Abs() <= maxUTCOffset {
+			// 时区偏移均为 15 分钟的整数倍，GPS 与相机时钟的秒级误差在此消除
+			minutes := int(math.Round(diff.Minutes()/15)) * 15
+			offset = &minutes
+		}
+	}
+
+	loc := time.Local
+	if offset != nil {
+		loc = time.FixedZone("", *offset*60)
+	}
+	taken := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, loc)
+	return taken, offset, nil
+}
+
+// gpsTime 读取 GPSDateStamp + GPSTimeStamp（UTC）
+func gpsTime(x *exif.Exif) (time.Time, bool) {
+	dateTag, err := x.Get(exif.GPSDateStamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	dateStr, err := dateTag.StringVal()
+	if err != nil {
+		return time.Time{}, false
+	}
+	date, err := time.Parse("2006:01:02", strings.TrimSpace(strings.TrimRight(dateStr, "\x00")))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	timeTag, err := x.Get(exif.GPSTimeStamp)
+	if err != nil || timeTag.Count < 3 {
+		return time.Time{}, false
+	}
+	var parts [3]float64
+	for i := range parts {
+		num, denom, err := timeTag.Rat2(i)
+		if err != nil || denom == 0 {
+			return time.Time{}, false
+		}
+		parts[i] = float64(num) / float64(denom)
+	}
+	seconds := parts[0]*3600 + parts[1]*60 + parts[2]
+	return date.Add(time.Duration(seconds * float64(time.Second))), true
+}
+
+// CaptureTime 返回 Photo.TakenAt：拍摄地的墙上时间（以 UTC 表示，按日期过滤时不受服务器/用户时区影响）。
+// 没有 EXIF 拍摄时间时使用 fallback（通常为上传时间）在服务器时区的墙上时间。
+func CaptureTime(info *models.ExifInfo, fallback time.Time) primitive.DateTime {
+	t := fallback.In(time.Local)
+	if info != nil && info.TakenAt != nil {
+		t = info.TakenAt.Time().In(time.Local)
+		if info.UTCOffset != nil {
+			t = t.In(time.FixedZone("", *info.UTCOffset*60))
+		}
+	}
+	return primitive.NewDateTimeFromTime(WallClockUTC(t))
+}
+
+// WallClockUTC 保留 t 在其时区下的年月日时分秒，换成 UTC 表示
+func WallClockUTC(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}